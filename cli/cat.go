@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"abc/reader"
+)
+
+const CAT_USAGE = `usage: aio cat [flags] <source>
+
+Write the content of source to stdout without touching disk. Checksums
+are verified after the content is written, so a mismatch only changes the
+exit code.
+
+flags:
+`
+
+func runCat(ctx context.Context, env Env, args []string) int {
+	var (
+		source    sourceFlags
+		progress  bool
+		checksums checksumFlag
+		limit     rateFlag
+	)
+	fs := newFlagSet("cat", env.Stderr, CAT_USAGE)
+	source.register(fs)
+	fs.BoolVar(&progress, "p", false, "show progress on stderr")
+	fs.BoolVar(&progress, "progress", false, "show progress on stderr")
+	fs.Var(&checksums, "checksum", "verify `ALGORITHM:DIGEST` once the content is written, may be repeated")
+	fs.Var(&limit, "limit", "cap bandwidth to `RATE`, e.g. 10MB/s")
+
+	sources, err := parseInterspersed(fs, args)
+	if err != nil {
+		return EXIT_USAGE
+	}
+	if len(sources) != 1 {
+		fmt.Fprintf(env.Stderr, "%s: cat: %s\n", PROGRAM_NAME, errSingleSource)
+		return EXIT_USAGE
+	}
+
+	r, code := openSource(ctx, env, sources[0], source)
+	if r == nil {
+		return code
+	}
+	defer r.Close()
+
+	var streamOptions []reader.StreamOption
+	for _, c := range checksums {
+		streamOptions = append(streamOptions, reader.WithChecksum(c.Algorithm, c.Expected))
+	}
+	if limit > 0 {
+		streamOptions = append(streamOptions, reader.WithRateLimiter(reader.NewRateLimiter(int64(limit))))
+	}
+	if progress {
+		streamOptions = append(streamOptions, reader.WithProgressReporter(reader.NewProgressRenderer(env.Stderr).Bar(sources[0])))
+	}
+
+	if _, err := r.StreamToContext(ctx, env.Stdout, streamOptions...); err != nil {
+		return fail(env, err)
+	}
+	return EXIT_OK
+}
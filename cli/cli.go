@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"abc/reader"
+)
+
+const (
+	PROGRAM_NAME = "aio"
+
+	DEFAULT_RETRIES       = 2
+	RETRY_INITIAL_BACKOFF = time.Second
+	RETRY_MAX_BACKOFF     = 30 * time.Second
+	RETRY_JITTER          = 0.2
+)
+
+const USAGE = `usage: aio <command> [flags] <source>...
+
+commands:
+  get   download sources into a directory
+  cat   write a source to stdout
+  info  describe a source without downloading it
+
+sources are file paths or file://, http://, https:// and s3:// URLs.
+"-" reads stdin, and stdin://NAME reads stdin saved as NAME.
+
+exit codes:
+  0 success, 1 failure, 2 usage error, 3 source not found,
+  4 network or HTTP error, 5 checksum mismatch, 6 destination error,
+  7 unsupported source or format, 130 interrupted
+`
+
+// Env carries the process streams so commands can be run in tests.
+type Env struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func DefaultEnv() Env {
+	return Env{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+type command func(ctx context.Context, env Env, args []string) int
+
+var commands = map[string]command{
+	"get":  runGet,
+	"cat":  runCat,
+	"info": runInfo,
+}
+
+// Run executes the command line args, without the program name, and returns
+// the process exit code.
+func Run(ctx context.Context, env Env, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(env.Stderr, USAGE)
+		return EXIT_USAGE
+	}
+	if args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(env.Stdout, USAGE)
+		return EXIT_OK
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(env.Stderr, "%s: unknown command %q\n\n%s", PROGRAM_NAME, args[0], USAGE)
+		return EXIT_USAGE
+	}
+
+	defer reader.SetDebugOutput(io.Discard)
+	return cmd(ctx, env, args[1:])
+}
+
+func fail(env Env, err error) int {
+	fmt.Fprintf(env.Stderr, "%s: %s\n", PROGRAM_NAME, err)
+	return exitCode(err)
+}
+
+var errSingleSource = errors.New("expected exactly one source")
+
+// normalizeSource lets plain paths stand for file:// sources. A lone "-"
+// is left for the reader to treat as stdin.
+func normalizeSource(source string) string {
+	if source == reader.STDIN_SOURCE {
+		return source
+	}
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" {
+		return source
+	}
+	return reader.SCHEME_FILE_PREFIX + source
+}
+
+func normalizeSources(sources []string) []string {
+	normalized := make([]string, 0, len(sources))
+	for _, source := range sources {
+		normalized = append(normalized, normalizeSource(source))
+	}
+	return normalized
+}
+
+// openSource opens a single source for the cat command.
+func openSource(ctx context.Context, env Env, source string, flags sourceFlags) (*reader.Reader, int) {
+	if flags.verbose {
+		reader.SetDebugOutput(env.Stderr)
+	}
+	r, err := reader.NewReaderContext(ctx, normalizeSource(source), flags.readerOptions(env)...)
+	if err != nil {
+		return nil, fail(env, err)
+	}
+	return r, EXIT_OK
+}
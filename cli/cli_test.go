@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"abc/reader"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	CLI_FILE_NAME    = "data.txt"
+	CLI_FILE_PATH    = "/" + CLI_FILE_NAME
+	CLI_FILE_CONTENT = "command line content"
+	CLI_BROKEN_PATH  = "/broken.txt"
+	CLI_MISSING_PATH = "/missing.txt"
+	CLI_SHA256       = "0d84a5f2a6bcd7ac0e8c4eba0f9e9ce5b0ec5d8c03e7e6ef3dc52aa1ebd3c9c1"
+)
+
+type CLITestSuite struct {
+	suite.Suite
+	server *httptest.Server
+	dir    string
+	stdin  string
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+func TestCLITestSuite(t *testing.T) {
+	suite.Run(t, new(CLITestSuite))
+}
+
+func (s *CLITestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	s.stdin = ""
+	s.stdout.Reset()
+	s.stderr.Reset()
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case CLI_FILE_PATH:
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("ETag", `"cli-v1"`)
+			io.WriteString(w, CLI_FILE_CONTENT)
+		case CLI_BROKEN_PATH:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func (s *CLITestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *CLITestSuite) run(args ...string) int {
+	env := Env{Stdin: strings.NewReader(s.stdin), Stdout: &s.stdout, Stderr: &s.stderr}
+	return Run(context.Background(), env, args)
+}
+
+func (s *CLITestSuite) url(path string) string {
+	return s.server.URL + path
+}
+
+func (s *CLITestSuite) assertFile(name, content string) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	s.Require().NoError(err)
+	s.Equal(content, string(data))
+}
+
+func (s *CLITestSuite) TestUsage() {
+	s.Equal(EXIT_USAGE, s.run())
+	s.Contains(s.stderr.String(), "usage: aio")
+
+	s.Equal(EXIT_USAGE, s.run("fetch"))
+	s.Contains(s.stderr.String(), `unknown command "fetch"`)
+
+	s.Equal(EXIT_OK, s.run("--help"))
+	s.Contains(s.stdout.String(), "exit codes")
+
+	s.Equal(EXIT_USAGE, s.run("get"))
+	s.Equal(EXIT_USAGE, s.run("get", "--no-such-flag", s.url(CLI_FILE_PATH)))
+	s.Equal(EXIT_USAGE, s.run("get", "-O", "x", s.url(CLI_FILE_PATH), s.url(CLI_FILE_PATH)))
+	s.Equal(EXIT_USAGE, s.run("get", "--limit", "10Mb/s", s.url(CLI_FILE_PATH)))
+}
+
+func (s *CLITestSuite) TestGetShouldAcceptFlagsAfterSources() {
+	s.Equal(EXIT_OK, s.run("get", s.url(CLI_FILE_PATH), "-o", s.dir, "-q"))
+	s.assertFile(CLI_FILE_NAME, CLI_FILE_CONTENT)
+	s.Empty(s.stdout.String())
+	s.Empty(s.stderr.String())
+}
+
+func (s *CLITestSuite) TestGetShouldReportSavedFilesOnStderr() {
+	s.Equal(EXIT_OK, s.run("get", "-o", s.dir, s.url(CLI_FILE_PATH)))
+	s.Empty(s.stdout.String())
+	s.Contains(s.stderr.String(), "created "+filepath.Join(s.dir, CLI_FILE_NAME))
+}
+
+func (s *CLITestSuite) TestGetShouldUseOutputName() {
+	s.Equal(EXIT_OK, s.run("get", "-q", "-o", s.dir, "-O", "renamed.txt", s.url(CLI_FILE_PATH)))
+	s.assertFile("renamed.txt", CLI_FILE_CONTENT)
+}
+
+func (s *CLITestSuite) TestGetShouldAcceptPlainPaths() {
+	source := filepath.Join(s.T().TempDir(), "local.txt")
+	s.Require().NoError(os.WriteFile(source, []byte(CLI_FILE_CONTENT), 0o644))
+
+	s.Equal(EXIT_OK, s.run("get", "-q", "-o", s.dir, source))
+	s.assertFile("local.txt", CLI_FILE_CONTENT)
+}
+
+func (s *CLITestSuite) TestCatShouldAcceptPathsWithURLCharacters() {
+	dir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "a"), []byte("decoy"), 0o644))
+
+	for _, name := range []string{"my file.txt", "a#1.txt", "100%.txt"} {
+		source := filepath.Join(dir, name)
+		s.Require().NoError(os.WriteFile(source, []byte(name), 0o644))
+
+		s.stdout.Reset()
+		s.Equal(EXIT_OK, s.run("cat", source), name)
+		s.Equal(name, s.stdout.String())
+	}
+}
+
+func (s *CLITestSuite) TestGetShouldReadStdin() {
+	s.stdin = CLI_FILE_CONTENT
+	s.Equal(EXIT_OK, s.run("get", "-q", "-o", s.dir, "-"))
+	s.assertFile(reader.STDIN_FILENAME+".txt", CLI_FILE_CONTENT)
+
+	s.Equal(EXIT_OK, s.run("get", "-q", "-o", s.dir, "stdin://piped.log"))
+	s.assertFile("piped.log", CLI_FILE_CONTENT)
+}
+
+func (s *CLITestSuite) TestGetShouldContinuePastFailures() {
+	code := s.run("get", "-q", "-o", s.dir, s.url(CLI_MISSING_PATH), s.url(CLI_FILE_PATH))
+	s.Equal(EXIT_NOT_FOUND, code)
+	s.Contains(s.stderr.String(), s.url(CLI_MISSING_PATH))
+	s.assertFile(CLI_FILE_NAME, CLI_FILE_CONTENT)
+}
+
+func (s *CLITestSuite) TestGetExitCodes() {
+	s.Equal(EXIT_NETWORK, s.run("get", "-q", "--retries", "0", "-o", s.dir, s.url(CLI_BROKEN_PATH)))
+	s.Equal(EXIT_UNSUPPORTED, s.run("get", "-q", "-o", s.dir, "ftp://example.com/file"))
+	s.Equal(EXIT_CHECKSUM, s.run("get", "-q", "-o", s.dir, "--checksum", "sha256:"+CLI_SHA256, s.url(CLI_FILE_PATH)))
+
+	s.Equal(EXIT_OK, s.run("get", "-q", "-o", s.dir, s.url(CLI_FILE_PATH)))
+	s.Equal(EXIT_DESTINATION, s.run("get", "-q", "-o", s.dir, "--on-exists", "fail", s.url(CLI_FILE_PATH)))
+
+	blocked := filepath.Join(s.dir, CLI_FILE_NAME, "out")
+	s.Equal(EXIT_DESTINATION, s.run("get", "-q", "-o", blocked, s.url(CLI_FILE_PATH)))
+	s.Equal(EXIT_FAILURE, s.run("get", "-q", "-o", s.T().TempDir(), s.dir))
+}
+
+func (s *CLITestSuite) TestGetJSONShouldWriteEventsToStdout() {
+	s.Equal(EXIT_OK, s.run("get", "--json", "-o", s.dir, s.url(CLI_FILE_PATH)))
+	s.Empty(s.stderr.String())
+
+	var types []string
+	scanner := bufio.NewScanner(&s.stdout)
+	for scanner.Scan() {
+		var event map[string]any
+		s.Require().NoError(json.Unmarshal(scanner.Bytes(), &event))
+		s.Equal(s.url(CLI_FILE_PATH), event["source"])
+		types = append(types, event["type"].(string))
+	}
+	s.Equal("start", types[0])
+	s.Equal("finish", types[len(types)-1])
+}
+
+func (s *CLITestSuite) TestCatShouldWriteContentToStdout() {
+	s.Equal(EXIT_OK, s.run("cat", s.url(CLI_FILE_PATH)))
+	s.Equal(CLI_FILE_CONTENT, s.stdout.String())
+
+	s.stdout.Reset()
+	s.Equal(EXIT_CHECKSUM, s.run("cat", "--checksum", "sha256:"+CLI_SHA256, s.url(CLI_FILE_PATH)))
+	s.Equal(CLI_FILE_CONTENT, s.stdout.String())
+	s.Contains(s.stderr.String(), "checksum mismatch")
+
+	s.stdout.Reset()
+	s.stdin = CLI_FILE_CONTENT
+	s.Equal(EXIT_OK, s.run("cat", "-"))
+	s.Equal(CLI_FILE_CONTENT, s.stdout.String())
+
+	s.Equal(EXIT_USAGE, s.run("cat"))
+	s.Equal(EXIT_NOT_FOUND, s.run("cat", s.url(CLI_MISSING_PATH)))
+}
+
+func (s *CLITestSuite) TestInfoShouldDescribeSource() {
+	s.Equal(EXIT_OK, s.run("info", s.url(CLI_FILE_PATH)))
+	s.Contains(s.stdout.String(), "url:         "+s.url(CLI_FILE_PATH))
+	s.Contains(s.stdout.String(), "filename:    "+CLI_FILE_NAME)
+	s.Contains(s.stdout.String(), "size:        20 (20 B)")
+	s.Contains(s.stdout.String(), `etag:        "cli-v1"`)
+	s.Contains(s.stdout.String(), "ranges:      false")
+
+	s.stdout.Reset()
+	s.Equal(EXIT_OK, s.run("info", "--json", s.url(CLI_FILE_PATH)))
+	var info map[string]any
+	s.Require().NoError(json.Unmarshal(s.stdout.Bytes(), &info))
+	s.Equal(CLI_FILE_NAME, info["filename"])
+	s.Equal("text/plain", info["content_type"])
+
+	s.Equal(EXIT_NOT_FOUND, s.run("info", s.url(CLI_MISSING_PATH)))
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+
+	apperrors "abc/errors"
+	"abc/reader"
+)
+
+const (
+	EXIT_OK          = 0
+	EXIT_FAILURE     = 1
+	EXIT_USAGE       = 2
+	EXIT_NOT_FOUND   = 3
+	EXIT_NETWORK     = 4
+	EXIT_CHECKSUM    = 5
+	EXIT_DESTINATION = 6
+	EXIT_UNSUPPORTED = 7
+	EXIT_INTERRUPTED = 130
+)
+
+// exitCode maps an error to the exit status documented in the usage text.
+func exitCode(err error) int {
+	var (
+		statusErr *apperrors.HTTPStatusError
+		netErr    net.Error
+		destErr   *apperrors.DestinationError
+		pathErr   *fs.PathError
+	)
+
+	switch {
+	case err == nil:
+		return EXIT_OK
+	case errors.Is(err, context.Canceled):
+		return EXIT_INTERRUPTED
+	case errors.Is(err, apperrors.ErrFileNotFound), errors.Is(err, apperrors.ErrURLNotExists):
+		return EXIT_NOT_FOUND
+	case errors.Is(err, apperrors.ErrChecksumMismatch):
+		return EXIT_CHECKSUM
+	case errors.Is(err, apperrors.ErrUnsupportedScheme), errors.Is(err, apperrors.ErrInvalidS3URL),
+		errors.Is(err, apperrors.ErrUnsupportedChecksum), errors.Is(err, apperrors.ErrUnknownCompression),
+		errors.Is(err, apperrors.ErrUnsupportedEncoding):
+		return EXIT_UNSUPPORTED
+	case errors.Is(err, apperrors.ErrFileExists), errors.Is(err, apperrors.ErrUnsafeFilename),
+		errors.As(err, &destErr):
+		return EXIT_DESTINATION
+	case errors.As(err, &pathErr):
+		return EXIT_FAILURE
+	case errors.As(err, &statusErr), errors.As(err, &netErr), reader.IsRetryable(err):
+		return EXIT_NETWORK
+	default:
+		return EXIT_FAILURE
+	}
+}
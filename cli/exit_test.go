@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	apperrors "abc/errors"
+	"abc/reader"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: nil, want: EXIT_OK},
+		{err: errors.New("boom"), want: EXIT_FAILURE},
+		{err: fmt.Errorf("wrapped: %w", context.Canceled), want: EXIT_INTERRUPTED},
+		{err: &apperrors.SourceNotFoundError{Path: "x", Err: fs.ErrNotExist}, want: EXIT_NOT_FOUND},
+		{err: &apperrors.HTTPStatusError{Code: http.StatusNotFound}, want: EXIT_NOT_FOUND},
+		{err: &apperrors.HTTPStatusError{Code: http.StatusForbidden}, want: EXIT_NETWORK},
+		{err: io.ErrUnexpectedEOF, want: EXIT_NETWORK},
+		{err: &reader.ChecksumMismatchError{Algorithm: reader.CHECKSUM_SHA256}, want: EXIT_CHECKSUM},
+		{err: &apperrors.UnsupportedSchemeError{Scheme: "ftp"}, want: EXIT_UNSUPPORTED},
+		{err: apperrors.ErrFileExists, want: EXIT_DESTINATION},
+		{err: &apperrors.DestinationError{Err: apperrors.ErrFileExists}, want: EXIT_DESTINATION},
+		{err: &apperrors.DestinationError{Err: &fs.PathError{Op: "open", Path: "/readonly", Err: fs.ErrPermission}}, want: EXIT_DESTINATION},
+		{err: fmt.Errorf("save: %w", &apperrors.DestinationError{Err: &fs.PathError{Op: "write", Path: "out/x.part", Err: errors.New("file too large")}}), want: EXIT_DESTINATION},
+		{err: &fs.PathError{Op: "read", Path: "/source", Err: fs.ErrPermission}, want: EXIT_FAILURE},
+	}
+
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Fatalf("%v: want %d got %d", tt.err, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"abc/reader"
+)
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments, as in `aio get URL -o DIR`. A lone "-" is a
+// positional argument and "--" ends flag parsing.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(name string, stderr io.Writer, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// checksumFlag collects repeated --checksum ALGORITHM:DIGEST values.
+type checksumFlag []reader.Checksum
+
+func (c *checksumFlag) String() string {
+	parts := make([]string, 0, len(*c))
+	for _, checksum := range *c {
+		parts = append(parts, string(checksum.Algorithm)+":"+checksum.Expected)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (c *checksumFlag) Set(value string) error {
+	algorithm, digest, ok := strings.Cut(value, ":")
+	if !ok || algorithm == "" || digest == "" {
+		return fmt.Errorf("expected ALGORITHM:DIGEST, got %q", value)
+	}
+	*c = append(*c, reader.Checksum{Algorithm: reader.ChecksumAlgorithm(strings.ToLower(algorithm)), Expected: digest})
+	return nil
+}
+
+type rateFlag int64
+
+func (r *rateFlag) String() string {
+	return fmt.Sprint(int64(*r))
+}
+
+func (r *rateFlag) Set(value string) error {
+	rate, err := reader.ParseRate(value)
+	if err != nil {
+		return err
+	}
+	*r = rateFlag(rate)
+	return nil
+}
+
+var collisionPolicies = map[string]reader.CollisionPolicy{
+	"rename":         reader.COLLISION_RENAME,
+	"overwrite":      reader.COLLISION_OVERWRITE,
+	"skip":           reader.COLLISION_SKIP_IF_EXISTS,
+	"skip-identical": reader.COLLISION_SKIP_IF_IDENTICAL,
+	"fail":           reader.COLLISION_FAIL,
+	"timestamp":      reader.COLLISION_TIMESTAMP,
+}
+
+type collisionFlag reader.CollisionPolicy
+
+func (c *collisionFlag) String() string {
+	for name, policy := range collisionPolicies {
+		if policy == reader.CollisionPolicy(*c) {
+			return name
+		}
+	}
+	return ""
+}
+
+func (c *collisionFlag) Set(value string) error {
+	policy, ok := collisionPolicies[value]
+	if !ok {
+		return fmt.Errorf("unknown policy %q, expected rename, overwrite, skip, skip-identical, fail or timestamp", value)
+	}
+	*c = collisionFlag(policy)
+	return nil
+}
+
+// sourceFlags are shared by every subcommand that opens a source.
+type sourceFlags struct {
+	retries    int
+	segments   int
+	decompress bool
+	discover   bool
+	verbose    bool
+}
+
+func (f *sourceFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&f.retries, "retries", DEFAULT_RETRIES, "retry transient failures up to `N` times")
+	fs.IntVar(&f.segments, "segments", 0, "download in `N` parallel ranges when the server allows it")
+	fs.BoolVar(&f.decompress, "decompress", false, "decompress gzip, bzip2, xz, zstd, lz4 and brotli content")
+	fs.BoolVar(&f.discover, "discover-checksums", false, "look for checksum sidecar files and digest headers")
+	fs.BoolVar(&f.verbose, "v", false, "write debug output to stderr")
+	fs.BoolVar(&f.verbose, "verbose", false, "write debug output to stderr")
+}
+
+func (f *sourceFlags) readerOptions(env Env) []reader.Option {
+	opts := []reader.Option{
+		reader.WithStdin(env.Stdin),
+		reader.WithRetry(reader.RetryPolicy{
+			MaxAttempts:    f.retries + 1,
+			InitialBackoff: RETRY_INITIAL_BACKOFF,
+			MaxBackoff:     RETRY_MAX_BACKOFF,
+			Jitter:         RETRY_JITTER,
+		}),
+	}
+	if f.segments > 1 {
+		opts = append(opts, reader.WithSegments(f.segments))
+	}
+	if f.decompress {
+		opts = append(opts, reader.WithDecompression(reader.DECOMPRESS_AUTO))
+	}
+	if f.discover {
+		opts = append(opts, reader.WithChecksumDiscovery())
+	}
+	return opts
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+
+	"abc/reader"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	output := fs.String("o", "", "")
+	quiet := fs.Bool("q", false, "")
+
+	args, err := parseInterspersed(fs, []string{"a", "-o", "dir", "b", "-q", "-", "--", "-c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"a", "b", "-", "-c"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("want %v got %v", want, args)
+	}
+	if *output != "dir" || !*quiet {
+		t.Fatalf("flags not parsed: output=%q quiet=%v", *output, *quiet)
+	}
+}
+
+func TestChecksumFlag(t *testing.T) {
+	var c checksumFlag
+	if err := c.Set("SHA256:abc"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Set("md5"); err == nil {
+		t.Fatalf("expected error for missing digest")
+	}
+	if want := (checksumFlag{{Algorithm: reader.CHECKSUM_SHA256, Expected: "abc"}}); !reflect.DeepEqual(c, want) {
+		t.Fatalf("want %v got %v", want, c)
+	}
+}
+
+func TestCollisionFlag(t *testing.T) {
+	var c collisionFlag
+	if err := c.Set("skip-identical"); err != nil || reader.CollisionPolicy(c) != reader.COLLISION_SKIP_IF_IDENTICAL {
+		t.Fatalf("unexpected result %v, %v", c, err)
+	}
+	if err := c.Set("clobber"); err == nil {
+		t.Fatalf("expected error for unknown policy")
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	apperrors "abc/errors"
+	"abc/reader"
+)
+
+const GET_USAGE = `usage: aio get [flags] <source>... [-o DIR]
+
+Download every source into DIR.
+
+flags:
+`
+
+func runGet(ctx context.Context, env Env, args []string) int {
+	var (
+		source    sourceFlags
+		output    string
+		name      string
+		quiet     bool
+		json      bool
+		workers   int
+		perHost   int
+		checksums checksumFlag
+		limit     rateFlag
+		onExists  collisionFlag
+	)
+
+	fs := newFlagSet("get", env.Stderr, GET_USAGE)
+	source.register(fs)
+	fs.StringVar(&output, "o", ".", "save downloads into `DIR`")
+	fs.StringVar(&output, "output", ".", "save downloads into `DIR`")
+	fs.StringVar(&name, "O", "", "save a single download as `NAME`")
+	fs.StringVar(&name, "output-name", "", "save a single download as `NAME`")
+	fs.BoolVar(&quiet, "q", false, "do not show progress or a summary")
+	fs.BoolVar(&quiet, "quiet", false, "do not show progress or a summary")
+	fs.BoolVar(&json, "json", false, "write NDJSON events to stdout")
+	fs.IntVar(&workers, "workers", reader.DEFAULT_DOWNLOAD_WORKERS, "download `N` sources at a time")
+	fs.IntVar(&perHost, "per-host", 0, "download at most `N` sources from one host at a time")
+	fs.Var(&checksums, "checksum", "verify `ALGORITHM:DIGEST`, may be repeated")
+	fs.Var(&limit, "limit", "cap total bandwidth to `RATE`, e.g. 10MB/s")
+	fs.Var(&onExists, "on-exists", "what to do when the file exists: rename, overwrite, skip, skip-identical, fail or timestamp")
+
+	sources, err := parseInterspersed(fs, args)
+	if err != nil {
+		return EXIT_USAGE
+	}
+	if len(sources) == 0 {
+		fs.Usage()
+		return EXIT_USAGE
+	}
+	if name != "" && len(sources) > 1 {
+		fmt.Fprintf(env.Stderr, "%s: -O can only be used with a single source\n", PROGRAM_NAME)
+		return EXIT_USAGE
+	}
+
+	if source.verbose {
+		reader.SetDebugOutput(env.Stderr)
+	}
+	if err := os.MkdirAll(output, 0o755); err != nil {
+		return fail(env, &apperrors.DestinationError{Err: err})
+	}
+
+	streamOptions := []reader.StreamOption{reader.WithCollisionPolicy(reader.CollisionPolicy(onExists))}
+	for _, c := range checksums {
+		streamOptions = append(streamOptions, reader.WithChecksum(c.Algorithm, c.Expected))
+	}
+	if name != "" {
+		streamOptions = append(streamOptions, reader.WithFilename(name))
+	}
+	if limit > 0 {
+		streamOptions = append(streamOptions, reader.WithRateLimiter(reader.NewRateLimiter(int64(limit))))
+	}
+
+	downloaderOptions := []reader.DownloaderOption{
+		reader.WithWorkers(workers),
+		reader.WithPerHostLimit(perHost),
+		reader.WithReaderOptions(source.readerOptions(env)...),
+		reader.WithStreamOptions(streamOptions...),
+	}
+	switch {
+	case json:
+		downloaderOptions = append(downloaderOptions, reader.WithEvents(reader.NewEventEmitter(env.Stdout, "")))
+	case !quiet:
+		renderer := reader.NewProgressRenderer(env.Stderr)
+		downloaderOptions = append(downloaderOptions, reader.WithItemProgress(func(source string) reader.ProgressReporter {
+			return renderer.Bar(source)
+		}))
+	}
+
+	results := reader.NewDownloader(downloaderOptions...).DownloadContext(ctx, output, normalizeSources(sources))
+
+	code := EXIT_OK
+	for i, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(env.Stderr, "%s: %s: %s\n", PROGRAM_NAME, sources[i], result.Err)
+			if code == EXIT_OK {
+				code = exitCode(result.Err)
+			}
+			continue
+		}
+		if !quiet && !json {
+			fmt.Fprintf(env.Stderr, "%s %s (%s)\n", result.Action, result.Path, humanSize(result.Size))
+		}
+	}
+	return code
+}
+
+func humanSize(bytes int64) string {
+	val, unit := reader.HumanizeReadableSize(bytes, reader.SIZE_UNITS_IEC)
+	if unit == "B" {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", val, unit)
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"abc/reader"
+)
+
+const INFO_USAGE = `usage: aio info [flags] <source>
+
+Describe source without downloading it.
+
+flags:
+`
+
+func runInfo(ctx context.Context, env Env, args []string) int {
+	var (
+		source   sourceFlags
+		jsonMode bool
+	)
+	fs := newFlagSet("info", env.Stderr, INFO_USAGE)
+	source.register(fs)
+	fs.BoolVar(&jsonMode, "json", false, "print the description as JSON")
+
+	sources, err := parseInterspersed(fs, args)
+	if err != nil {
+		return EXIT_USAGE
+	}
+	if len(sources) != 1 {
+		fmt.Fprintf(env.Stderr, "%s: info: %s\n", PROGRAM_NAME, errSingleSource)
+		return EXIT_USAGE
+	}
+
+	if source.verbose {
+		reader.SetDebugOutput(env.Stderr)
+	}
+	info, err := reader.StatContext(ctx, normalizeSource(sources[0]), source.readerOptions(env)...)
+	if err != nil {
+		return fail(env, err)
+	}
+	info.Source = sources[0]
+
+	if jsonMode {
+		if err := json.NewEncoder(env.Stdout).Encode(info); err != nil {
+			return fail(env, err)
+		}
+		return EXIT_OK
+	}
+
+	printInfoField(env, "source", info.Source)
+	printInfoField(env, "url", info.URL)
+	printInfoField(env, "filename", info.Filename)
+	if info.Size > 0 {
+		printInfoField(env, "size", fmt.Sprintf("%d (%s)", info.Size, humanSize(info.Size)))
+	} else {
+		printInfoField(env, "size", "unknown")
+	}
+	printInfoField(env, "type", info.ContentType)
+	if !info.LastModified.IsZero() {
+		printInfoField(env, "modified", info.LastModified.UTC().Format(time.RFC3339))
+	}
+	printInfoField(env, "etag", info.ETag)
+	printInfoField(env, "ranges", strconv.FormatBool(info.AcceptRanges))
+	printInfoField(env, "compression", string(info.Compression))
+	printInfoField(env, "encoding", info.ContentEncoding)
+	return EXIT_OK
+}
+
+// printInfoField prints one aligned line, skipping empty values.
+func printInfoField(env Env, name, value string) {
+	if value != "" {
+		fmt.Fprintf(env.Stdout, "%-12s %s\n", name+":", value)
+	}
+}
@@ -1,8 +1,55 @@
 package errors
 
+import "errors"
+
 const (
 	ERR_UNSUPPORTED_SCHEME = "unsupported scheme"
 	ERR_FILE_NOT_FOUND     = "file not found"
 	ERR_URL_NOT_EXISTS     = "url not exists"
 	ERR_READER_SOURCE_NIL  = "reader source is nil"
+
+	ERR_RANGE_NOT_SATISFIED = "range request not satisfied"
+	ERR_INVALID_S3_URL      = "invalid s3 url, expected s3://bucket/key"
+
+	ERR_CHECKSUM_MISMATCH    = "checksum mismatch"
+	ERR_UNSUPPORTED_CHECKSUM = "unsupported checksum algorithm"
+
+	ERR_UNKNOWN_COMPRESSION  = "unknown compression format"
+	ERR_UNSUPPORTED_ENCODING = "unsupported content encoding"
+
+	ERR_UNSAFE_FILENAME = "filename escapes destination folder"
+	ERR_FILE_EXISTS     = "destination file already exists"
+
+	ERR_INVALID_RATE = "invalid rate, expected a value like 10MB/s"
+
+	ERR_HTTP_STATUS = "unexpected http status"
+
+	ERR_SINK_TIMEOUT = "sink write timed out"
+	ERR_NO_SINKS     = "every sink failed"
+)
+
+var (
+	ErrUnsupportedScheme = errors.New(ERR_UNSUPPORTED_SCHEME)
+	ErrFileNotFound      = errors.New(ERR_FILE_NOT_FOUND)
+	ErrURLNotExists      = errors.New(ERR_URL_NOT_EXISTS)
+	ErrReaderSourceNil   = errors.New(ERR_READER_SOURCE_NIL)
+
+	ErrRangeNotSatisfied = errors.New(ERR_RANGE_NOT_SATISFIED)
+	ErrInvalidS3URL      = errors.New(ERR_INVALID_S3_URL)
+
+	ErrChecksumMismatch    = errors.New(ERR_CHECKSUM_MISMATCH)
+	ErrUnsupportedChecksum = errors.New(ERR_UNSUPPORTED_CHECKSUM)
+
+	ErrUnknownCompression  = errors.New(ERR_UNKNOWN_COMPRESSION)
+	ErrUnsupportedEncoding = errors.New(ERR_UNSUPPORTED_ENCODING)
+
+	ErrUnsafeFilename = errors.New(ERR_UNSAFE_FILENAME)
+	ErrFileExists     = errors.New(ERR_FILE_EXISTS)
+
+	ErrInvalidRate = errors.New(ERR_INVALID_RATE)
+
+	ErrHTTPStatus = errors.New(ERR_HTTP_STATUS)
+
+	ErrSinkTimeout = errors.New(ERR_SINK_TIMEOUT)
+	ErrNoSinks     = errors.New(ERR_NO_SINKS)
 )
@@ -0,0 +1,104 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPStatusError is returned when a server answers with a status the reader
+// cannot use. 404 and 410 match ErrURLNotExists, every status matches
+// ErrHTTPStatus.
+type HTTPStatusError struct {
+	Code       int
+	URL        string
+	Header     http.Header
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	reason := ERR_HTTP_STATUS
+	if e.notFound() {
+		reason = ERR_URL_NOT_EXISTS
+	}
+	return fmt.Sprintf("%s: %d %s: %s", reason, e.Code, http.StatusText(e.Code), e.URL)
+}
+
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrHTTPStatus || (target == ErrURLNotExists && e.notFound())
+}
+
+// Retryable reports whether the status is transient: timeouts, rate limiting
+// and server-side failures other than 501.
+func (e *HTTPStatusError) Retryable() bool {
+	switch e.Code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (e *HTTPStatusError) notFound() bool {
+	return e.Code == http.StatusNotFound || e.Code == http.StatusGone
+}
+
+// SourceNotFoundError is returned when a local source does not exist. Err
+// holds the underlying cause, typically fs.ErrNotExist.
+type SourceNotFoundError struct {
+	Path string
+	Err  error
+}
+
+func (e *SourceNotFoundError) Error() string {
+	return fmt.Sprintf("%s: %s", ERR_FILE_NOT_FOUND, e.Path)
+}
+
+func (e *SourceNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SourceNotFoundError) Is(target error) bool {
+	return target == ErrFileNotFound
+}
+
+func (e *SourceNotFoundError) Retryable() bool {
+	return false
+}
+
+type UnsupportedSchemeError struct {
+	Scheme string
+}
+
+func (e *UnsupportedSchemeError) Error() string {
+	return fmt.Sprintf("%s: %q", ERR_UNSUPPORTED_SCHEME, e.Scheme)
+}
+
+func (e *UnsupportedSchemeError) Is(target error) bool {
+	return target == ErrUnsupportedScheme
+}
+
+func (e *UnsupportedSchemeError) Retryable() bool {
+	return false
+}
+
+// DestinationError marks a failure on the output side of a download, such as
+// creating the destination folder or part file or moving the finished file
+// into place. It reads as its cause, so it only tags where the failure
+// happened.
+type DestinationError struct {
+	Err error
+}
+
+func (e *DestinationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DestinationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DestinationError) Retryable() bool {
+	return false
+}
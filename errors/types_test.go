@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatusError_IsAndRetryable(t *testing.T) {
+	tests := []struct {
+		code      int
+		notExists bool
+		retryable bool
+	}{
+		{code: http.StatusNotFound, notExists: true},
+		{code: http.StatusGone, notExists: true},
+		{code: http.StatusForbidden},
+		{code: http.StatusNotImplemented},
+		{code: http.StatusInternalServerError, retryable: true},
+		{code: http.StatusTooManyRequests, retryable: true},
+	}
+
+	for _, tt := range tests {
+		err := fmt.Errorf("probe: %w", &HTTPStatusError{Code: tt.code, URL: "https://example.com/file"})
+		if !errors.Is(err, ErrHTTPStatus) {
+			t.Fatalf("%d: expected ErrHTTPStatus", tt.code)
+		}
+		if got := errors.Is(err, ErrURLNotExists); got != tt.notExists {
+			t.Fatalf("%d: ErrURLNotExists want %v got %v", tt.code, tt.notExists, got)
+		}
+
+		var se *HTTPStatusError
+		if !errors.As(err, &se) || se.Retryable() != tt.retryable {
+			t.Fatalf("%d: retryable want %v", tt.code, tt.retryable)
+		}
+	}
+}
+
+func TestHTTPStatusError_Message(t *testing.T) {
+	err := &HTTPStatusError{Code: http.StatusInternalServerError, URL: "https://example.com/file"}
+	want := "unexpected http status: 500 Internal Server Error: https://example.com/file"
+	if err.Error() != want {
+		t.Fatalf("want %q got %q", want, err.Error())
+	}
+}
+
+func TestSourceNotFoundError_WrapsCause(t *testing.T) {
+	err := error(&SourceNotFoundError{Path: "missing.txt", Err: fs.ErrNotExist})
+	if !errors.Is(err, ErrFileNotFound) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected both sentinel and cause to match")
+	}
+	if err.Error() != "file not found: missing.txt" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+func TestUnsupportedSchemeError(t *testing.T) {
+	err := error(&UnsupportedSchemeError{Scheme: "ftp"})
+	if !errors.Is(err, ErrUnsupportedScheme) {
+		t.Fatalf("expected ErrUnsupportedScheme")
+	}
+	if err.Error() != `unsupported scheme: "ftp"` {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+func TestDestinationError_ReadsAsCause(t *testing.T) {
+	cause := &fs.PathError{Op: "open", Path: "out/file.part", Err: fs.ErrPermission}
+	err := error(&DestinationError{Err: cause})
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("expected the cause to match")
+	}
+	if err.Error() != cause.Error() {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
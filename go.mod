@@ -2,7 +2,19 @@ module abc
 
 go 1.25.4
 
-require github.com/stretchr/testify v1.11.1
+require (
+	github.com/andybalholm/brotli v1.2.6
+	github.com/klauspost/compress v1.20.1
+	github.com/pierrec/lz4/v4 v4.1.31
+	github.com/stretchr/testify v1.11.1
+	github.com/ulikunitz/xz v0.5.17
+)
+
+require (
+	golang.org/x/crypto v0.45.0
+	golang.org/x/sys v0.38.0
+	golang.org/x/term v0.37.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -0,0 +1,110 @@
+package reader
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	apperrors "abc/errors"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+type ChecksumAlgorithm string
+
+const (
+	CHECKSUM_MD5     ChecksumAlgorithm = "md5"
+	CHECKSUM_SHA1    ChecksumAlgorithm = "sha1"
+	CHECKSUM_SHA256  ChecksumAlgorithm = "sha256"
+	CHECKSUM_SHA512  ChecksumAlgorithm = "sha512"
+	CHECKSUM_BLAKE2B ChecksumAlgorithm = "blake2b"
+)
+
+type Checksum struct {
+	Algorithm ChecksumAlgorithm
+	Expected  string
+}
+
+type ChecksumMismatchError struct {
+	Algorithm ChecksumAlgorithm
+	Expected  string
+	Actual    string
+	Path      string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s: %s expected %s, got %s", apperrors.ERR_CHECKSUM_MISMATCH, e.Algorithm, e.Expected, e.Actual)
+}
+
+func (e *ChecksumMismatchError) Is(target error) bool {
+	return target == apperrors.ErrChecksumMismatch
+}
+
+type checksumVerifier struct {
+	checksums []Checksum
+	hashes    []hash.Hash
+	writer    io.Writer
+}
+
+func newChecksumVerifier(checksums []Checksum) (*checksumVerifier, error) {
+	v := &checksumVerifier{}
+	writers := make([]io.Writer, 0, len(checksums))
+	for _, c := range checksums {
+		c.Expected = normalizeDigest(c.Expected)
+		h, err := newChecksumHash(c)
+		if err != nil {
+			return nil, err
+		}
+		v.checksums = append(v.checksums, c)
+		v.hashes = append(v.hashes, h)
+		writers = append(writers, h)
+	}
+	v.writer = io.MultiWriter(writers...)
+	return v, nil
+}
+
+func (v *checksumVerifier) Write(p []byte) (int, error) {
+	return v.writer.Write(p)
+}
+
+func (v *checksumVerifier) verify(path string) error {
+	for i, c := range v.checksums {
+		actual := hex.EncodeToString(v.hashes[i].Sum(nil))
+		if actual != c.Expected {
+			return &ChecksumMismatchError{Algorithm: c.Algorithm, Expected: c.Expected, Actual: actual, Path: path}
+		}
+	}
+	return nil
+}
+
+func newChecksumHash(c Checksum) (hash.Hash, error) {
+	switch ChecksumAlgorithm(strings.ToLower(string(c.Algorithm))) {
+	case CHECKSUM_MD5:
+		return md5.New(), nil
+	case CHECKSUM_SHA1:
+		return sha1.New(), nil
+	case CHECKSUM_SHA256:
+		return sha256.New(), nil
+	case CHECKSUM_SHA512:
+		return sha512.New(), nil
+	case CHECKSUM_BLAKE2B:
+		// the digest length selects the variant, e.g. 64 hex chars for BLAKE2b-256
+		size := len(c.Expected) / 2
+		if size == 0 || size > blake2b.Size {
+			size = blake2b.Size
+		}
+		return blake2b.New(size, nil)
+	default:
+		return nil, apperrors.ErrUnsupportedChecksum
+	}
+}
+
+func normalizeDigest(digest string) string {
+	return strings.ToLower(strings.TrimSpace(digest))
+}
@@ -0,0 +1,204 @@
+package reader
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	HEADER_REPR_DIGEST = "Repr-Digest"
+	HEADER_DIGEST      = "Digest"
+	HEADER_GOOG_HASH   = "X-Goog-Hash"
+	HEADER_CONTENT_MD5 = "Content-MD5"
+
+	CHECKSUM_FILE_MAX_SIZE = 1 << 20
+)
+
+// ChecksumSourceReader is implemented by sources that know the expected
+// digest of their content; StreamToFile verifies against it when no checksum
+// was passed explicitly.
+type ChecksumSourceReader interface {
+	SourceReader
+	Checksums() []Checksum
+}
+
+type checksumSidecar struct {
+	algorithm ChecksumAlgorithm
+	suffix    string
+	sumsFile  string
+}
+
+var checksumSidecars = []checksumSidecar{
+	{algorithm: CHECKSUM_SHA512, suffix: ".sha512", sumsFile: "SHA512SUMS"},
+	{algorithm: CHECKSUM_SHA256, suffix: ".sha256", sumsFile: "SHA256SUMS"},
+	{algorithm: CHECKSUM_SHA1, suffix: ".sha1", sumsFile: "SHA1SUMS"},
+	{algorithm: CHECKSUM_MD5, suffix: ".md5", sumsFile: "MD5SUMS"},
+}
+
+var digestAlgorithms = map[string]ChecksumAlgorithm{
+	"md5":     CHECKSUM_MD5,
+	"sha":     CHECKSUM_SHA1,
+	"sha-1":   CHECKSUM_SHA1,
+	"sha-256": CHECKSUM_SHA256,
+	"sha-512": CHECKSUM_SHA512,
+}
+
+func discoverChecksums(ctx context.Context, client *http.Client, source string, header http.Header) []Checksum {
+	if !isContentEncoded(header.Get(HEADER_CONTENT_ENCODING)) {
+		if checksums := parseDigestHeaders(header); len(checksums) > 0 {
+			return checksums
+		}
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil
+	}
+	name := path.Base(u.Path)
+
+	for _, sidecar := range checksumSidecars {
+		sidecarURL := *u
+		sidecarURL.Path += sidecar.suffix
+		sidecarURL.RawPath = ""
+		if content, ok := fetchChecksumFile(ctx, client, sidecarURL.String()); ok {
+			if digest, ok := parseChecksumFile(content, name); ok {
+				return []Checksum{{Algorithm: sidecar.algorithm, Expected: digest}}
+			}
+		}
+	}
+
+	for _, sidecar := range checksumSidecars {
+		sumsURL := u.ResolveReference(&url.URL{Path: sidecar.sumsFile})
+		if content, ok := fetchChecksumFile(ctx, client, sumsURL.String()); ok {
+			if digest, ok := parseChecksumFile(content, name); ok {
+				return []Checksum{{Algorithm: sidecar.algorithm, Expected: digest}}
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseDigestHeaders(header http.Header) []Checksum {
+	var checksums []Checksum
+
+	// RFC 9530: Repr-Digest: sha-256=:<base64>:
+	// RFC 3230: Digest: SHA-256=<base64>
+	for _, name := range []string{HEADER_REPR_DIGEST, HEADER_DIGEST} {
+		for _, value := range header.Values(name) {
+			for _, member := range strings.Split(value, ",") {
+				algo, encoded, ok := strings.Cut(strings.TrimSpace(member), "=")
+				if !ok {
+					continue
+				}
+				algorithm, known := digestAlgorithms[strings.ToLower(strings.TrimSpace(algo))]
+				if !known {
+					continue
+				}
+				if digest, ok := base64ToHex(strings.Trim(strings.TrimSpace(encoded), ":")); ok {
+					checksums = append(checksums, Checksum{Algorithm: algorithm, Expected: digest})
+				}
+			}
+		}
+		if len(checksums) > 0 {
+			return checksums
+		}
+	}
+
+	// x-goog-hash: crc32c=<base64>, md5=<base64>
+	for _, value := range header.Values(HEADER_GOOG_HASH) {
+		for _, member := range strings.Split(value, ",") {
+			algo, encoded, ok := strings.Cut(strings.TrimSpace(member), "=")
+			if !ok || strings.ToLower(algo) != string(CHECKSUM_MD5) {
+				continue
+			}
+			if digest, ok := base64ToHex(encoded); ok {
+				return []Checksum{{Algorithm: CHECKSUM_MD5, Expected: digest}}
+			}
+		}
+	}
+
+	if digest, ok := base64ToHex(header.Get(HEADER_CONTENT_MD5)); ok {
+		return []Checksum{{Algorithm: CHECKSUM_MD5, Expected: digest}}
+	}
+	return nil
+}
+
+// parseChecksumFile finds the digest for name in GNU coreutils format
+// ("<hex>  <name>" or "<hex> *<name>"). A file holding a single bare digest
+// is accepted as well, as many .sha256 sidecars are written that way.
+func parseChecksumFile(content string, name string) (string, bool) {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	for _, line := range lines {
+		escaped := strings.HasPrefix(line, "\\")
+		line = strings.TrimPrefix(line, "\\")
+
+		digest, file, ok := strings.Cut(line, " ")
+		if !ok || !isHexDigest(digest) {
+			continue
+		}
+		file = strings.TrimPrefix(strings.TrimPrefix(file, " "), "*")
+		if escaped {
+			file = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r").Replace(file)
+		}
+		if file == name || path.Base(file) == name {
+			return strings.ToLower(digest), true
+		}
+	}
+
+	if len(lines) == 1 && isHexDigest(lines[0]) {
+		return strings.ToLower(lines[0]), true
+	}
+	return "", false
+}
+
+func fetchChecksumFile(ctx context.Context, client *http.Client, source string) (string, bool) {
+	resp, err := doRequest(ctx, client, http.MethodGet, source, nil)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, CHECKSUM_FILE_MAX_SIZE))
+	if err != nil {
+		return "", false
+	}
+	return string(content), true
+}
+
+func base64ToHex(encoded string) (string, bool) {
+	if encoded == "" {
+		return "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) == 0 {
+		return "", false
+	}
+	return hex.EncodeToString(raw), true
+}
+
+func isHexDigest(s string) bool {
+	if len(s) < 32 || len(s)%2 != 0 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
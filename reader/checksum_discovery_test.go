@@ -0,0 +1,161 @@
+package reader
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	DISC_ISO_NAME        = "ubuntu-24.04-desktop-amd64.iso"
+	DISC_ISO_PATH        = "/releases/" + DISC_ISO_NAME
+	DISC_SUMS_PATH       = "/releases/SHA256SUMS"
+	DISC_BAD_ISO_PATH    = "/broken/" + DISC_ISO_NAME
+	DISC_BAD_SUMS_PATH   = "/broken/SHA256SUMS"
+	DISC_SIDECAR_NAME    = "tool.tar"
+	DISC_SIDECAR_PATH    = "/sidecar/" + DISC_SIDECAR_NAME
+	DISC_SIDECAR_SHA512  = DISC_SIDECAR_PATH + ".sha512"
+	DISC_HEADER_PATH     = "/header/data.bin"
+	DISC_CONTENT         = "pretend this is an iso image"
+	DISC_OTHER_FILE_LINE = "1111111111111111111111111111111111111111111111111111111111111111 *other.iso\n"
+)
+
+type ChecksumDiscoveryTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	requests []string
+}
+
+func TestChecksumDiscoveryTestSuite(t *testing.T) {
+	suite.Run(t, new(ChecksumDiscoveryTestSuite))
+}
+
+func (s *ChecksumDiscoveryTestSuite) SetupTest() {
+	s.requests = nil
+	sha256Sum := sha256.Sum256([]byte(DISC_CONTENT))
+	sha512Sum := sha512.Sum512([]byte(DISC_CONTENT))
+
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case DISC_ISO_PATH, DISC_BAD_ISO_PATH, DISC_SIDECAR_PATH:
+			io.WriteString(w, DISC_CONTENT)
+		case DISC_SUMS_PATH:
+			io.WriteString(w, DISC_OTHER_FILE_LINE+hex.EncodeToString(sha256Sum[:])+" *"+DISC_ISO_NAME+"\n")
+		case DISC_BAD_SUMS_PATH:
+			io.WriteString(w, CHECKSUM_WRONG_DIGEST+"  "+DISC_ISO_NAME+"\n")
+		case DISC_SIDECAR_SHA512:
+			io.WriteString(w, hex.EncodeToString(sha512Sum[:])+"\n")
+		case DISC_HEADER_PATH:
+			w.Header().Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sha256Sum[:])+":")
+			io.WriteString(w, DISC_CONTENT)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func (s *ChecksumDiscoveryTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestShouldDiscoverChecksumFromSHA256SUMS() {
+	r, err := NewHTTPReader(s.server.URL+DISC_ISO_PATH, WithChecksumDiscovery())
+	s.NoError(err)
+
+	sum := sha256.Sum256([]byte(DISC_CONTENT))
+	s.Equal([]Checksum{{Algorithm: CHECKSUM_SHA256, Expected: hex.EncodeToString(sum[:])}}, r.Checksums())
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestShouldPreferPerFileSidecar() {
+	r, err := NewHTTPReader(s.server.URL+DISC_SIDECAR_PATH, WithChecksumDiscovery())
+	s.NoError(err)
+
+	s.Len(r.Checksums(), 1)
+	s.Equal(CHECKSUM_SHA512, r.Checksums()[0].Algorithm)
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestShouldDiscoverChecksumFromReprDigestHeader() {
+	r, err := NewHTTPReader(s.server.URL+DISC_HEADER_PATH, WithChecksumDiscovery())
+	s.NoError(err)
+
+	s.Len(r.Checksums(), 1)
+	s.Equal(CHECKSUM_SHA256, r.Checksums()[0].Algorithm)
+	s.Equal([]string{"HEAD " + DISC_HEADER_PATH}, s.requests)
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestShouldNotDiscoverWithoutOptIn() {
+	r, err := NewHTTPReader(s.server.URL + DISC_ISO_PATH)
+	s.NoError(err)
+
+	s.Empty(r.Checksums())
+	s.Equal([]string{"HEAD " + DISC_ISO_PATH}, s.requests)
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestStreamToFileShouldVerifyDiscoveredChecksum() {
+	r, err := NewReader(s.server.URL+DISC_ISO_PATH, WithChecksumDiscovery())
+	s.NoError(err)
+
+	_, _, err = r.StreamToFile(s.T().TempDir())
+	s.NoError(err)
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestStreamToFileShouldFailOnDiscoveredMismatch() {
+	r, err := NewReader(s.server.URL+DISC_BAD_ISO_PATH, WithChecksumDiscovery())
+	s.NoError(err)
+
+	_, _, err = r.StreamToFile(s.T().TempDir())
+	var mismatch *ChecksumMismatchError
+	s.ErrorAs(err, &mismatch)
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestStreamToFileExplicitChecksumShouldOverrideDiscovery() {
+	r, err := NewReader(s.server.URL+DISC_BAD_ISO_PATH, WithChecksumDiscovery())
+	s.NoError(err)
+
+	sum := md5.Sum([]byte(DISC_CONTENT))
+	_, _, err = r.StreamToFile(s.T().TempDir(), WithChecksum(CHECKSUM_MD5, hex.EncodeToString(sum[:])))
+	s.NoError(err)
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestParseChecksumFileShouldHandleCoreutilsFormat() {
+	digest := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	got, ok := parseChecksumFile("# comment\n"+DISC_OTHER_FILE_LINE+digest+" *"+DISC_ISO_NAME+"\n", DISC_ISO_NAME)
+	s.True(ok)
+	s.Equal(digest, got)
+
+	got, ok = parseChecksumFile(digest+"  ./dir/"+DISC_ISO_NAME, DISC_ISO_NAME)
+	s.True(ok)
+	s.Equal(digest, got)
+
+	got, ok = parseChecksumFile(`\`+digest+`  back\\slash.iso`, `back\slash.iso`)
+	s.True(ok)
+	s.Equal(digest, got)
+
+	got, ok = parseChecksumFile(digest+"\n", "anything")
+	s.True(ok)
+	s.Equal(digest, got)
+
+	_, ok = parseChecksumFile(DISC_OTHER_FILE_LINE+digest+"  second.iso\n", DISC_ISO_NAME)
+	s.False(ok)
+}
+
+func (s *ChecksumDiscoveryTestSuite) TestParseDigestHeadersShouldSupportKnownHeaders() {
+	md5Sum := md5.Sum([]byte(DISC_CONTENT))
+	encoded := base64.StdEncoding.EncodeToString(md5Sum[:])
+	want := []Checksum{{Algorithm: CHECKSUM_MD5, Expected: hex.EncodeToString(md5Sum[:])}}
+
+	s.Equal(want, parseDigestHeaders(http.Header{"Digest": {"MD5=" + encoded}}))
+	s.Equal(want, parseDigestHeaders(http.Header{"X-Goog-Hash": {"crc32c=n03x6A==,md5=" + encoded}}))
+	s.Equal(want, parseDigestHeaders(http.Header{"Content-Md5": {encoded}}))
+	s.Empty(parseDigestHeaders(http.Header{"Digest": {"unixsum=30637"}}))
+}
@@ -0,0 +1,159 @@
+package reader
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apperrors "abc/errors"
+
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/blake2b"
+)
+
+const CHECKSUM_WRONG_DIGEST = "0000000000000000000000000000000000000000000000000000000000000000"
+
+func hexDigest(sum []byte) string {
+	return hex.EncodeToString(sum)
+}
+
+type ChecksumTestSuite struct {
+	suite.Suite
+}
+
+func TestChecksumTestSuite(t *testing.T) {
+	suite.Run(t, new(ChecksumTestSuite))
+}
+
+func (s *ChecksumTestSuite) localDigests() map[ChecksumAlgorithm]string {
+	content := []byte(FILE_LOCAL_CONTENT)
+	md5Sum := md5.Sum(content)
+	sha1Sum := sha1.Sum(content)
+	sha256Sum := sha256.Sum256(content)
+	sha512Sum := sha512.Sum512(content)
+	blake2bSum := blake2b.Sum512(content)
+	return map[ChecksumAlgorithm]string{
+		CHECKSUM_MD5:     hexDigest(md5Sum[:]),
+		CHECKSUM_SHA1:    hexDigest(sha1Sum[:]),
+		CHECKSUM_SHA256:  hexDigest(sha256Sum[:]),
+		CHECKSUM_SHA512:  hexDigest(sha512Sum[:]),
+		CHECKSUM_BLAKE2B: hexDigest(blake2bSum[:]),
+	}
+}
+
+func (s *ChecksumTestSuite) TestStreamToFileShouldVerifyEveryAlgorithm() {
+	for algorithm, digest := range s.localDigests() {
+		r, err := NewReader(FILE_LOCAL_SCHEME)
+		s.NoError(err)
+
+		dest := s.T().TempDir()
+		path, _, err := r.StreamToFile(dest, WithChecksum(algorithm, strings.ToUpper(digest)))
+		s.NoError(err, algorithm)
+		s.Equal(FILE_LOCAL_NAME, filepath.Base(path), algorithm)
+	}
+}
+
+func (s *ChecksumTestSuite) TestStreamToFileShouldSupportBlake2b256() {
+	sum := blake2b.Sum256([]byte(FILE_LOCAL_CONTENT))
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	_, _, err = r.StreamToFile(s.T().TempDir(), WithChecksum(CHECKSUM_BLAKE2B, hexDigest(sum[:])))
+	s.NoError(err)
+}
+
+func (s *ChecksumTestSuite) TestStreamToFileShouldKeepPartFileOnMismatch() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	path, n, err := r.StreamToFile(dest, WithChecksum(CHECKSUM_SHA256, CHECKSUM_WRONG_DIGEST))
+	s.Error(err)
+	s.Equal(strings.TrimSuffix(r.partFilePath(dest), PART_FILE_SUFFIX)+CORRUPT_FILE_SUFFIX, path)
+	s.Equal(int64(FILE_LOCAL_SIZE), n)
+
+	var mismatch *ChecksumMismatchError
+	s.True(errors.As(err, &mismatch))
+	s.Equal(CHECKSUM_SHA256, mismatch.Algorithm)
+	s.Equal(CHECKSUM_WRONG_DIGEST, mismatch.Expected)
+	s.Equal(s.localDigests()[CHECKSUM_SHA256], mismatch.Actual)
+	s.Equal(path, mismatch.Path)
+
+	_, statErr := os.Stat(filepath.Join(dest, FILE_LOCAL_NAME))
+	s.True(os.IsNotExist(statErr))
+	_, statErr = os.Stat(path)
+	s.NoError(statErr)
+	_, statErr = os.Stat(r.partFilePath(dest))
+	s.True(os.IsNotExist(statErr))
+}
+
+func (s *ChecksumTestSuite) TestMismatchShouldPointAtPartFileWhenMoveFails() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	corruptPath := strings.TrimSuffix(r.partFilePath(dest), PART_FILE_SUFFIX) + CORRUPT_FILE_SUFFIX
+	s.NoError(os.MkdirAll(filepath.Join(corruptPath, FILE_LOCAL_NAME), 0o755))
+
+	path, _, err := r.StreamToFile(dest, WithChecksum(CHECKSUM_SHA256, CHECKSUM_WRONG_DIGEST))
+	var mismatch *ChecksumMismatchError
+	s.Require().True(errors.As(err, &mismatch))
+	s.Equal(r.partFilePath(dest), path)
+	s.Equal(path, mismatch.Path)
+
+	info, statErr := os.Stat(path)
+	s.Require().NoError(statErr)
+	s.Zero(info.Size())
+}
+
+func (s *ChecksumTestSuite) TestStreamToFileShouldRecoverFromCorruptPartFile() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	corrupt := strings.Repeat("x", FILE_LOCAL_SIZE)
+	s.NoError(os.WriteFile(r.partFilePath(dest), []byte(corrupt), 0o644))
+
+	digest := WithChecksum(CHECKSUM_SHA256, s.localDigests()[CHECKSUM_SHA256])
+	_, _, err = r.StreamToFile(dest, digest)
+	s.ErrorIs(err, apperrors.ErrChecksumMismatch)
+
+	r, err = NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+	path, _, err := r.StreamToFile(dest, digest)
+	s.NoError(err)
+	data, err := os.ReadFile(path)
+	s.NoError(err)
+	s.Equal(FILE_LOCAL_CONTENT, string(data))
+}
+
+func (s *ChecksumTestSuite) TestStreamToFileShouldVerifyResumedDownload() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	s.NoError(os.WriteFile(r.partFilePath(dest), []byte(FILE_LOCAL_CONTENT[:5]), 0o644))
+
+	_, _, err = r.StreamToFile(dest, WithChecksum(CHECKSUM_SHA256, s.localDigests()[CHECKSUM_SHA256]))
+	s.NoError(err)
+}
+
+func (s *ChecksumTestSuite) TestStreamToFileShouldRejectUnsupportedAlgorithm() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	path, _, err := r.StreamToFile(dest, WithChecksum("crc64", "abc"))
+	s.Equal("", path)
+	s.Equal("unsupported checksum algorithm", err.Error())
+
+	entries, _ := os.ReadDir(dest)
+	s.Empty(entries)
+}
@@ -0,0 +1,188 @@
+package reader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	apperrors "abc/errors"
+)
+
+type CollisionPolicy int
+
+const (
+	COLLISION_RENAME CollisionPolicy = iota
+	COLLISION_OVERWRITE
+	COLLISION_SKIP_IF_IDENTICAL
+	COLLISION_SKIP_IF_EXISTS
+	COLLISION_FAIL
+	COLLISION_TIMESTAMP
+)
+
+type FinalizeAction string
+
+const (
+	ACTION_CREATED     FinalizeAction = "created"
+	ACTION_RENAMED     FinalizeAction = "renamed"
+	ACTION_OVERWRITTEN FinalizeAction = "overwritten"
+	ACTION_SKIPPED     FinalizeAction = "skipped"
+
+	TIMESTAMP_SUFFIX_LAYOUT = "20060102T150405Z"
+)
+
+// precheckCollision settles policies that can be decided before any bytes are
+// transferred: an existing file is enough for skip and fail, and for
+// skip-if-identical when its size and the expected checksums already match.
+func (r *Reader) precheckCollision(finalPath string, policy CollisionPolicy, checksums []Checksum) (StreamResult, bool, error) {
+	info, err := os.Stat(finalPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return StreamResult{}, false, nil
+	}
+	if err != nil {
+		return StreamResult{}, false, err
+	}
+	existing := StreamResult{Path: finalPath, Size: info.Size(), Action: ACTION_SKIPPED}
+
+	switch policy {
+	case COLLISION_SKIP_IF_EXISTS:
+		return existing, true, nil
+	case COLLISION_FAIL:
+		return StreamResult{}, false, apperrors.ErrFileExists
+	case COLLISION_SKIP_IF_IDENTICAL:
+		// checksums describe the source bytes, which is what is on disk only
+		// without decompression
+		if len(checksums) == 0 || r.decompression != DECOMPRESS_OFF || info.Size() != r.src.TotalSize() {
+			return StreamResult{}, false, nil
+		}
+		if matchesChecksums(finalPath, checksums) {
+			return existing, true, nil
+		}
+	}
+	return StreamResult{}, false, nil
+}
+
+// finalizeFile moves the verified part file into place according to policy.
+// Policies that keep the existing file remove the part file instead.
+func finalizeFile(tempPath, finalPath string, policy CollisionPolicy) (string, FinalizeAction, error) {
+	path, action, err := moveIntoPlace(tempPath, finalPath, policy)
+	return path, action, destinationError(err)
+}
+
+func moveIntoPlace(tempPath, finalPath string, policy CollisionPolicy) (string, FinalizeAction, error) {
+	switch policy {
+	case COLLISION_OVERWRITE:
+		action := ACTION_CREATED
+		if _, err := os.Lstat(finalPath); err == nil {
+			action = ACTION_OVERWRITTEN
+		}
+		if err := os.Rename(tempPath, finalPath); err != nil {
+			return "", "", err
+		}
+		return finalPath, action, syncDir(filepath.Dir(finalPath))
+	case COLLISION_RENAME:
+		path, err := claimFinalPath(tempPath, finalPath)
+		if path != finalPath && path != "" {
+			return path, ACTION_RENAMED, err
+		}
+		return path, ACTION_CREATED, err
+	}
+
+	err := renameNoReplace(tempPath, finalPath)
+	if err == nil {
+		return finalPath, ACTION_CREATED, syncDir(filepath.Dir(finalPath))
+	}
+	if !errors.Is(err, fs.ErrExist) {
+		return "", "", err
+	}
+
+	switch policy {
+	case COLLISION_SKIP_IF_EXISTS:
+		return finalPath, ACTION_SKIPPED, os.Remove(tempPath)
+	case COLLISION_SKIP_IF_IDENTICAL:
+		same, err := sameFileContent(tempPath, finalPath)
+		if err != nil {
+			return "", "", err
+		}
+		if same {
+			return finalPath, ACTION_SKIPPED, os.Remove(tempPath)
+		}
+		path, err := claimFinalPath(tempPath, finalPath)
+		return path, ACTION_RENAMED, err
+	case COLLISION_TIMESTAMP:
+		path, err := claimFinalPath(tempPath, timestampFilePath(finalPath, time.Now()))
+		return path, ACTION_RENAMED, err
+	default:
+		return "", "", apperrors.ErrFileExists
+	}
+}
+
+func timestampFilePath(originalPath string, now time.Time) string {
+	dir := filepath.Dir(originalPath)
+	base := filepath.Base(originalPath)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	return filepath.Join(dir, name+"_"+now.UTC().Format(TIMESTAMP_SUFFIX_LAYOUT)+ext)
+}
+
+func matchesChecksums(path string, checksums []Checksum) bool {
+	verifier, err := newChecksumVerifier(checksums)
+	if err != nil {
+		return false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(verifier, f); err != nil {
+		return false
+	}
+	return verifier.verify(path) == nil
+}
+
+func sameFileContent(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	sumA, err := fileSHA256(a)
+	if err != nil {
+		return false, err
+	}
+	sumB, err := fileSHA256(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(sumA, sumB), nil
+}
+
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,165 @@
+package reader
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	apperrors "abc/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	COLLISION_EXISTING_CONTENT = "an older version"
+	COLLISION_TIMESTAMP_NAME   = `^test_\d{8}T\d{6}Z\.txt$`
+)
+
+type CollisionTestSuite struct {
+	suite.Suite
+	dest string
+}
+
+func TestCollisionTestSuite(t *testing.T) {
+	suite.Run(t, new(CollisionTestSuite))
+}
+
+func (s *CollisionTestSuite) SetupTest() {
+	s.dest = s.T().TempDir()
+}
+
+func (s *CollisionTestSuite) writeExisting(content string) string {
+	path := filepath.Join(s.dest, FILE_LOCAL_NAME)
+	s.Require().NoError(os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func (s *CollisionTestSuite) save(opts ...StreamOption) (StreamResult, error) {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.Require().NoError(err)
+	return r.Save(s.dest, opts...)
+}
+
+func (s *CollisionTestSuite) assertContent(path, content string) {
+	data, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Equal(content, string(data))
+}
+
+func (s *CollisionTestSuite) assertNoPartFiles() {
+	matches, err := filepath.Glob(filepath.Join(s.dest, "*"+PART_FILE_SUFFIX))
+	s.Require().NoError(err)
+	s.Empty(matches)
+}
+
+func (s *CollisionTestSuite) TestShouldReportCreatedWithoutCollision() {
+	for _, policy := range []CollisionPolicy{COLLISION_RENAME, COLLISION_OVERWRITE, COLLISION_SKIP_IF_IDENTICAL, COLLISION_SKIP_IF_EXISTS, COLLISION_FAIL, COLLISION_TIMESTAMP} {
+		s.dest = s.T().TempDir()
+		result, err := s.save(WithCollisionPolicy(policy))
+		s.NoError(err)
+		s.Equal(ACTION_CREATED, result.Action)
+		s.Equal(filepath.Join(s.dest, FILE_LOCAL_NAME), result.Path)
+	}
+}
+
+func (s *CollisionTestSuite) TestRenameShouldBeDefault() {
+	existing := s.writeExisting(COLLISION_EXISTING_CONTENT)
+
+	result, err := s.save()
+	s.NoError(err)
+	s.Equal(ACTION_RENAMED, result.Action)
+	s.Equal(filepath.Join(s.dest, FILE_LOCAL_UNIQUE_1), result.Path)
+	s.assertContent(existing, COLLISION_EXISTING_CONTENT)
+}
+
+func (s *CollisionTestSuite) TestOverwriteShouldReplaceExistingFile() {
+	existing := s.writeExisting(COLLISION_EXISTING_CONTENT)
+
+	result, err := s.save(WithCollisionPolicy(COLLISION_OVERWRITE))
+	s.NoError(err)
+	s.Equal(ACTION_OVERWRITTEN, result.Action)
+	s.Equal(existing, result.Path)
+	s.assertContent(existing, FILE_LOCAL_CONTENT)
+}
+
+func (s *CollisionTestSuite) TestSkipIfExistsShouldKeepExistingFile() {
+	existing := s.writeExisting(COLLISION_EXISTING_CONTENT)
+
+	result, err := s.save(WithCollisionPolicy(COLLISION_SKIP_IF_EXISTS))
+	s.NoError(err)
+	s.Equal(ACTION_SKIPPED, result.Action)
+	s.Equal(existing, result.Path)
+	s.Equal(int64(len(COLLISION_EXISTING_CONTENT)), result.Size)
+	s.assertContent(existing, COLLISION_EXISTING_CONTENT)
+	s.assertNoPartFiles()
+}
+
+func (s *CollisionTestSuite) TestFailShouldReturnError() {
+	existing := s.writeExisting(COLLISION_EXISTING_CONTENT)
+
+	_, err := s.save(WithCollisionPolicy(COLLISION_FAIL))
+	s.EqualError(err, apperrors.ERR_FILE_EXISTS)
+	s.assertContent(existing, COLLISION_EXISTING_CONTENT)
+	s.assertNoPartFiles()
+}
+
+func (s *CollisionTestSuite) TestSkipIfIdenticalShouldSkipSameContent() {
+	existing := s.writeExisting(FILE_LOCAL_CONTENT)
+
+	result, err := s.save(WithCollisionPolicy(COLLISION_SKIP_IF_IDENTICAL))
+	s.NoError(err)
+	s.Equal(ACTION_SKIPPED, result.Action)
+	s.Equal(existing, result.Path)
+	s.assertNoPartFiles()
+}
+
+func (s *CollisionTestSuite) TestSkipIfIdenticalShouldUseExpectedChecksumUpFront() {
+	existing := s.writeExisting(FILE_LOCAL_CONTENT)
+	sum := sha256.Sum256([]byte(FILE_LOCAL_CONTENT))
+
+	result, err := s.save(WithCollisionPolicy(COLLISION_SKIP_IF_IDENTICAL), WithChecksum(CHECKSUM_SHA256, hexDigest(sum[:])))
+	s.NoError(err)
+	s.Equal(ACTION_SKIPPED, result.Action)
+	s.Equal(existing, result.Path)
+	s.assertNoPartFiles()
+}
+
+func (s *CollisionTestSuite) TestSkipIfIdenticalShouldRenameDifferentContent() {
+	existing := s.writeExisting(COLLISION_EXISTING_CONTENT)
+
+	result, err := s.save(WithCollisionPolicy(COLLISION_SKIP_IF_IDENTICAL))
+	s.NoError(err)
+	s.Equal(ACTION_RENAMED, result.Action)
+	s.Equal(filepath.Join(s.dest, FILE_LOCAL_UNIQUE_1), result.Path)
+	s.assertContent(existing, COLLISION_EXISTING_CONTENT)
+	s.assertContent(result.Path, FILE_LOCAL_CONTENT)
+}
+
+func (s *CollisionTestSuite) TestTimestampShouldSuffixName() {
+	s.writeExisting(COLLISION_EXISTING_CONTENT)
+
+	result, err := s.save(WithCollisionPolicy(COLLISION_TIMESTAMP))
+	s.NoError(err)
+	s.Equal(ACTION_RENAMED, result.Action)
+	s.Regexp(regexp.MustCompile(COLLISION_TIMESTAMP_NAME), filepath.Base(result.Path))
+	s.assertContent(result.Path, FILE_LOCAL_CONTENT)
+}
+
+func (s *CollisionTestSuite) TestFinalizeShouldNotClobberFileCreatedDuringDownload() {
+	existing := s.writeExisting(COLLISION_EXISTING_CONTENT)
+	temp := filepath.Join(s.dest, FILE_LOCAL_NAME+PART_FILE_SUFFIX)
+	s.Require().NoError(os.WriteFile(temp, []byte(FILE_LOCAL_CONTENT), 0o644))
+
+	_, _, err := finalizeFile(temp, existing, COLLISION_FAIL)
+	s.EqualError(err, apperrors.ERR_FILE_EXISTS)
+	s.assertContent(existing, COLLISION_EXISTING_CONTENT)
+
+	path, action, err := finalizeFile(temp, existing, COLLISION_SKIP_IF_EXISTS)
+	s.NoError(err)
+	s.Equal(ACTION_SKIPPED, action)
+	s.Equal(existing, path)
+	s.assertContent(existing, COLLISION_EXISTING_CONTENT)
+	s.assertNoPartFiles()
+}
@@ -0,0 +1,89 @@
+package reader
+
+import (
+	"io"
+	"strings"
+
+	apperrors "abc/errors"
+)
+
+const (
+	HEADER_ACCEPT_ENCODING = "Accept-Encoding"
+
+	HTTP_ACCEPT_ENCODING = "gzip, deflate, br, zstd"
+	ENCODING_IDENTITY    = "identity"
+)
+
+var contentEncodings = map[string]CompressionFormat{
+	"gzip":    COMPRESSION_GZIP,
+	"x-gzip":  COMPRESSION_GZIP,
+	"deflate": COMPRESSION_ZLIB,
+	"br":      COMPRESSION_BROTLI,
+	"zstd":    COMPRESSION_ZSTD,
+}
+
+// WireCounter is implemented by sources that undo a transfer encoding while
+// reading. WireBytes reports the encoded bytes received, which is what
+// TotalSize describes, so progress is accounted on the wire.
+type WireCounter interface {
+	WireBytes() int64
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	*c.count += int64(n)
+	return n, err
+}
+
+type decodedBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	if c, ok := d.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return d.closer.Close()
+}
+
+func isContentEncoded(contentEncoding string) bool {
+	for _, coding := range strings.Split(contentEncoding, ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "" && coding != ENCODING_IDENTITY {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeContentEncoding undoes the codings listed in a Content-Encoding
+// header, last applied first, counting the raw bytes read into wire.
+func decodeContentEncoding(body io.ReadCloser, contentEncoding string, wire *int64) (io.ReadCloser, error) {
+	var reader io.Reader = &countingReader{reader: body, count: wire}
+
+	codings := strings.Split(contentEncoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		if coding == "" || coding == ENCODING_IDENTITY {
+			continue
+		}
+
+		format, ok := contentEncodings[coding]
+		if !ok {
+			return nil, apperrors.ErrUnsupportedEncoding
+		}
+		decoder, err := newDecoder(reader, format)
+		if err != nil {
+			return nil, err
+		}
+		reader = decoder
+	}
+
+	return &decodedBody{Reader: reader, closer: body}, nil
+}
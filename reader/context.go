@@ -0,0 +1,43 @@
+package reader
+
+import (
+	"context"
+	"io"
+)
+
+// ContextualSourceReader is implemented by sources whose requests and reads
+// can be bound to a context, so cancelling it aborts I/O already in flight.
+type ContextualSourceReader interface {
+	SourceReader
+	SetContext(ctx context.Context)
+}
+
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+func orBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+func mergeContext(parent, other context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(orBackground(parent))
+	stop := context.AfterFunc(orBackground(other), func() {
+		cancel(context.Cause(other))
+	})
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
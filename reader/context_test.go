@@ -0,0 +1,153 @@
+package reader
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	CTX_STALL_FILE_NAME = "stall.bin"
+	CTX_STALL_FILE_PATH = SLASH + CTX_STALL_FILE_NAME
+	CTX_UNAVAILABLE     = SLASH + "unavailable"
+	CTX_FIRST_CHUNK     = "first-chunk-"
+)
+
+type ContextTestSuite struct {
+	suite.Suite
+	server  *httptest.Server
+	started chan struct{}
+	release chan struct{}
+}
+
+func TestContextTestSuite(t *testing.T) {
+	suite.Run(t, new(ContextTestSuite))
+}
+
+func (s *ContextTestSuite) SetupTest() {
+	s.started = make(chan struct{}, 1)
+	s.release = make(chan struct{})
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case CTX_STALL_FILE_PATH:
+			w.Header().Set("Content-Length", "1000")
+			w.Header().Set("ETag", `"stall-v1"`)
+			w.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodHead {
+				return
+			}
+			io.WriteString(w, CTX_FIRST_CHUNK)
+			w.(http.Flusher).Flush()
+			s.started <- struct{}{}
+			select {
+			case <-r.Context().Done():
+			case <-s.release:
+			}
+		case CTX_UNAVAILABLE:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func (s *ContextTestSuite) TearDownTest() {
+	close(s.release)
+	s.server.Close()
+}
+
+func (s *ContextTestSuite) streamAndCancel(opts ...StreamOption) (string, string, error) {
+	r, err := NewReaderContext(context.Background(), s.server.URL+CTX_STALL_FILE_PATH)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	partPath := r.partFilePath(dest)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-s.started
+		cancel()
+	}()
+
+	path, _, err := r.StreamToFileContext(ctx, dest, opts...)
+	return path, partPath, err
+}
+
+func (s *ContextTestSuite) TestNewReaderContextShouldAbortHeadProbe() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r, err := NewReaderContext(ctx, s.server.URL+CTX_STALL_FILE_PATH)
+	s.Nil(r)
+	s.ErrorIs(err, context.Canceled)
+}
+
+func (s *ContextTestSuite) TestNewReaderContextShouldAbortRetryBackoff() {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Minute}
+	start := time.Now()
+	_, err := NewReaderContext(ctx, s.server.URL+CTX_UNAVAILABLE, WithRetry(policy))
+	s.Error(err)
+	s.Less(time.Since(start), 5*time.Second)
+}
+
+func (s *ContextTestSuite) TestStreamToFileContextShouldAbortInFlightBodyAndKeepPartFile() {
+	path, partPath, err := s.streamAndCancel()
+	s.Error(err)
+	s.Equal(partPath, path)
+
+	data, readErr := os.ReadFile(partPath)
+	s.NoError(readErr)
+	s.True(strings.HasPrefix(CTX_FIRST_CHUNK, string(data)))
+}
+
+func (s *ContextTestSuite) TestStreamToFileContextShouldRemovePartFileOnCancel() {
+	path, partPath, err := s.streamAndCancel(WithPartFilePolicy(PART_FILE_REMOVE_ON_CANCEL))
+	s.Error(err)
+	s.Equal("", path)
+
+	_, statErr := os.Stat(partPath)
+	s.True(os.IsNotExist(statErr))
+}
+
+func (s *ContextTestSuite) TestStreamToFileContextShouldAbortFileReads() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dest := s.T().TempDir()
+	path, n, err := r.StreamToFileContext(ctx, dest, WithPartFilePolicy(PART_FILE_REMOVE_ON_ERROR))
+	s.ErrorIs(err, context.Canceled)
+	s.Equal("", path)
+	s.Equal(int64(0), n)
+
+	entries, readErr := os.ReadDir(dest)
+	s.NoError(readErr)
+	s.Empty(entries)
+}
+
+func (s *ContextTestSuite) TestStreamToFileContextShouldRestoreReaderContext() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	path, _, err := r.StreamToFileContext(context.Background(), dest)
+	s.NoError(err)
+	s.Equal(FILE_LOCAL_NAME, filepath.Base(path))
+
+	data, err := io.ReadAll(r)
+	s.NoError(err)
+	s.True(strings.HasPrefix(FILE_LOCAL_CONTENT, string(data)))
+}
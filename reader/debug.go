@@ -0,0 +1,14 @@
+package reader
+
+import (
+	"io"
+	"log"
+)
+
+var debugLog = log.New(io.Discard, "debug: ", log.LstdFlags)
+
+// SetDebugOutput enables diagnostic logging to w. Debug output is discarded
+// by default and should go to stderr so stdout stays machine-readable.
+func SetDebugOutput(w io.Writer) {
+	debugLog.SetOutput(w)
+}
@@ -0,0 +1,208 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+
+	apperrors "abc/errors"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+)
+
+type DecompressionMode int
+
+const (
+	DECOMPRESS_OFF DecompressionMode = iota
+	DECOMPRESS_AUTO
+	DECOMPRESS_FORCE
+)
+
+type CompressionFormat string
+
+const (
+	COMPRESSION_NONE   CompressionFormat = ""
+	COMPRESSION_GZIP   CompressionFormat = "gzip"
+	COMPRESSION_BZIP2  CompressionFormat = "bzip2"
+	COMPRESSION_XZ     CompressionFormat = "xz"
+	COMPRESSION_ZSTD   CompressionFormat = "zstd"
+	COMPRESSION_LZ4    CompressionFormat = "lz4"
+	COMPRESSION_BROTLI CompressionFormat = "brotli"
+	COMPRESSION_ZLIB   CompressionFormat = "zlib"
+
+	COMPRESSION_MAGIC_SIZE = 6
+)
+
+type compressionMagic struct {
+	format CompressionFormat
+	magic  []byte
+}
+
+var compressionMagics = []compressionMagic{
+	{format: COMPRESSION_GZIP, magic: []byte{0x1f, 0x8b}},
+	{format: COMPRESSION_XZ, magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{format: COMPRESSION_ZSTD, magic: []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{format: COMPRESSION_LZ4, magic: []byte{0x04, 0x22, 0x4d, 0x18}},
+}
+
+type compressionExtension struct {
+	format      CompressionFormat
+	ext         string
+	replacement string
+}
+
+var compressionExtensions = []compressionExtension{
+	{format: COMPRESSION_GZIP, ext: ".tgz", replacement: ".tar"},
+	{format: COMPRESSION_GZIP, ext: ".gz"},
+	{format: COMPRESSION_GZIP, ext: ".gzip"},
+	{format: COMPRESSION_BZIP2, ext: ".tbz2", replacement: ".tar"},
+	{format: COMPRESSION_BZIP2, ext: ".bz2"},
+	{format: COMPRESSION_XZ, ext: ".txz", replacement: ".tar"},
+	{format: COMPRESSION_XZ, ext: ".xz"},
+	{format: COMPRESSION_ZSTD, ext: ".zst"},
+	{format: COMPRESSION_ZSTD, ext: ".zstd"},
+	{format: COMPRESSION_LZ4, ext: ".lz4"},
+	{format: COMPRESSION_BROTLI, ext: ".br"},
+	{format: COMPRESSION_ZLIB, ext: ".zz"},
+	{format: COMPRESSION_ZLIB, ext: ".zlib"},
+}
+
+func detectCompressionByExtension(filename string) CompressionFormat {
+	lower := strings.ToLower(filename)
+	for _, e := range compressionExtensions {
+		if strings.HasSuffix(lower, e.ext) {
+			return e.format
+		}
+	}
+	return COMPRESSION_NONE
+}
+
+func detectCompressionByMagic(header []byte) CompressionFormat {
+	for _, m := range compressionMagics {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.format
+		}
+	}
+	// "BZh" is followed by the block size, '1' to '9'
+	if len(header) >= 4 && bytes.HasPrefix(header, []byte("BZh")) && header[3] >= '1' && header[3] <= '9' {
+		return COMPRESSION_BZIP2
+	}
+	// zlib has no real magic; accept only the common CMF/FLG pairs whose
+	// second byte is outside printable ASCII so plain text is not mistaken.
+	if len(header) >= 2 && header[0] == 0x78 &&
+		(header[1] == 0x01 || header[1] == 0x9c || header[1] == 0xda) {
+		return COMPRESSION_ZLIB
+	}
+	return COMPRESSION_NONE
+}
+
+func detectCompression(header []byte, filename string) CompressionFormat {
+	if format := detectCompressionByMagic(header); format != COMPRESSION_NONE {
+		return format
+	}
+	// brotli streams carry no signature, so only the extension can tell
+	if format := detectCompressionByExtension(filename); format == COMPRESSION_BROTLI {
+		return format
+	}
+	return COMPRESSION_NONE
+}
+
+func StripCompressionExtension(filename string, format CompressionFormat) string {
+	lower := strings.ToLower(filename)
+	for _, e := range compressionExtensions {
+		if e.format == format && strings.HasSuffix(lower, e.ext) && len(filename) > len(e.ext) {
+			return filename[:len(filename)-len(e.ext)] + e.replacement
+		}
+	}
+	return filename
+}
+
+// decompress peeks at the start of r and, depending on mode, wraps it in a
+// decoder for the detected format. It returns r untouched when mode is off or
+// nothing was detected in auto mode.
+func decompress(r io.Reader, filename string, mode DecompressionMode) (io.Reader, CompressionFormat, error) {
+	if mode == DECOMPRESS_OFF {
+		return r, COMPRESSION_NONE, nil
+	}
+
+	br := bufio.NewReader(r)
+	header, err := br.Peek(COMPRESSION_MAGIC_SIZE)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, COMPRESSION_NONE, err
+	}
+
+	format := detectCompression(header, filename)
+	if format == COMPRESSION_NONE {
+		if mode == DECOMPRESS_FORCE {
+			return nil, COMPRESSION_NONE, apperrors.ErrUnknownCompression
+		}
+		return br, COMPRESSION_NONE, nil
+	}
+
+	decoder, err := newDecoder(br, format)
+	if err != nil {
+		return nil, format, err
+	}
+	if closer, ok := decoder.(io.ReadCloser); ok {
+		return &closingDecoder{ReadCloser: closer}, format, nil
+	}
+	return decoder, format, nil
+}
+
+// closingDecoder releases a decoder, such as zstd's with its goroutines, as
+// soon as its stream ends. Close may be called again at any time.
+type closingDecoder struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (d *closingDecoder) Read(p []byte) (int, error) {
+	if d.closed {
+		return 0, io.EOF
+	}
+	n, err := d.ReadCloser.Read(p)
+	if err != nil {
+		d.Close()
+	}
+	return n, err
+}
+
+func (d *closingDecoder) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	return d.ReadCloser.Close()
+}
+
+func newDecoder(r io.Reader, format CompressionFormat) (io.Reader, error) {
+	switch format {
+	case COMPRESSION_GZIP:
+		return gzip.NewReader(r)
+	case COMPRESSION_BZIP2:
+		return bzip2.NewReader(r), nil
+	case COMPRESSION_XZ:
+		return xz.NewReader(r)
+	case COMPRESSION_ZSTD:
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	case COMPRESSION_LZ4:
+		return lz4.NewReader(r), nil
+	case COMPRESSION_BROTLI:
+		return brotli.NewReader(r), nil
+	case COMPRESSION_ZLIB:
+		return zlib.NewReader(r)
+	default:
+		return nil, apperrors.ErrUnknownCompression
+	}
+}
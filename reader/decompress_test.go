@@ -0,0 +1,215 @@
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/suite"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	DECOMP_CONTENT = "compressed sample text\n"
+	// printf 'compressed sample text\n' | bzip2 -9 | base64
+	DECOMP_BZIP2_BASE64 = "QlpoOTFBWSZTWQJNfW4AAAJRgAAQQAAuBtxAIAAimBNM9UEAAAiR1piTphpyr8y/F3JFOFCQAk19bg=="
+)
+
+type DecompressTestSuite struct {
+	suite.Suite
+}
+
+func TestDecompressTestSuite(t *testing.T) {
+	suite.Run(t, new(DecompressTestSuite))
+}
+
+func (s *DecompressTestSuite) compress(format CompressionFormat) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+
+	switch format {
+	case COMPRESSION_GZIP:
+		w = gzip.NewWriter(&buf)
+	case COMPRESSION_ZLIB:
+		w = zlib.NewWriter(&buf)
+	case COMPRESSION_XZ:
+		w, err = xz.NewWriter(&buf)
+	case COMPRESSION_ZSTD:
+		w, err = zstd.NewWriter(&buf)
+	case COMPRESSION_LZ4:
+		w = lz4.NewWriter(&buf)
+	case COMPRESSION_BROTLI:
+		w = brotli.NewWriter(&buf)
+	case COMPRESSION_BZIP2:
+		data, decodeErr := base64.StdEncoding.DecodeString(DECOMP_BZIP2_BASE64)
+		s.Require().NoError(decodeErr)
+		return data
+	}
+	s.Require().NoError(err)
+
+	_, err = io.WriteString(w, DECOMP_CONTENT)
+	s.Require().NoError(err)
+	s.Require().NoError(w.Close())
+	return buf.Bytes()
+}
+
+func (s *DecompressTestSuite) writeSource(name string, data []byte) string {
+	path := filepath.Join(s.T().TempDir(), name)
+	s.Require().NoError(os.WriteFile(path, data, 0o644))
+	return "file://" + path
+}
+
+func (s *DecompressTestSuite) TestStreamToFileShouldDecompressEveryFormat() {
+	files := map[CompressionFormat]string{
+		COMPRESSION_GZIP:   "sample.txt.gz",
+		COMPRESSION_BZIP2:  "sample.txt.bz2",
+		COMPRESSION_XZ:     "sample.txt.xz",
+		COMPRESSION_ZSTD:   "sample.txt.zst",
+		COMPRESSION_LZ4:    "sample.txt.lz4",
+		COMPRESSION_BROTLI: "sample.txt.br",
+		COMPRESSION_ZLIB:   "sample.txt.zz",
+	}
+
+	for format, name := range files {
+		r, err := NewReader(s.writeSource(name, s.compress(format)), WithDecompression(DECOMPRESS_AUTO))
+		s.Require().NoError(err, format)
+
+		path, n, err := r.StreamToFile(s.T().TempDir())
+		s.NoError(err, format)
+		s.Equal("sample.txt", filepath.Base(path), format)
+		s.Equal(int64(len(DECOMP_CONTENT)), n, format)
+
+		data, readErr := os.ReadFile(path)
+		s.NoError(readErr)
+		s.Equal(DECOMP_CONTENT, string(data), format)
+	}
+}
+
+func (s *DecompressTestSuite) TestAutoShouldDetectByMagicBytesRegardlessOfExtension() {
+	r, err := NewReader(s.writeSource("payload.bin", s.compress(COMPRESSION_ZSTD)), WithDecompression(DECOMPRESS_AUTO))
+	s.NoError(err)
+
+	data, err := io.ReadAll(r)
+	s.NoError(err)
+	s.Equal(DECOMP_CONTENT, string(data))
+	s.Equal("payload.bin", r.Filename())
+}
+
+func (s *DecompressTestSuite) TestAutoShouldPassThroughPlainContent() {
+	r, err := NewReader(FILE_LOCAL_SCHEME, WithDecompression(DECOMPRESS_AUTO))
+	s.NoError(err)
+
+	path, n, err := r.StreamToFile(s.T().TempDir())
+	s.NoError(err)
+	s.Equal(FILE_LOCAL_NAME, filepath.Base(path))
+	s.Equal(int64(FILE_LOCAL_SIZE), n)
+}
+
+func (s *DecompressTestSuite) TestOffShouldKeepCompressedBytes() {
+	compressed := s.compress(COMPRESSION_GZIP)
+	r, err := NewReader(s.writeSource("sample.txt.gz", compressed))
+	s.NoError(err)
+
+	path, _, err := r.StreamToFile(s.T().TempDir())
+	s.NoError(err)
+	s.Equal("sample.txt.gz", filepath.Base(path))
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(compressed, data)
+}
+
+func (s *DecompressTestSuite) TestForceShouldFailOnUncompressedContent() {
+	r, err := NewReader(FILE_LOCAL_SCHEME, WithDecompression(DECOMPRESS_FORCE))
+	s.NoError(err)
+
+	_, err = io.ReadAll(r)
+	s.Error(err)
+	s.Equal("unknown compression format", err.Error())
+}
+
+func (s *DecompressTestSuite) TestChecksumShouldCoverSourceBytes() {
+	compressed := s.compress(COMPRESSION_GZIP)
+	r, err := NewReader(s.writeSource("sample.txt.gz", compressed), WithDecompression(DECOMPRESS_AUTO))
+	s.NoError(err)
+
+	verifier, err := newChecksumVerifier([]Checksum{{Algorithm: CHECKSUM_SHA256}})
+	s.NoError(err)
+	verifier.Write(compressed)
+	digest := verifier.hashes[0].Sum(nil)
+
+	_, _, err = r.StreamToFile(s.T().TempDir(), WithChecksum(CHECKSUM_SHA256, hexDigest(digest)))
+	s.NoError(err)
+}
+
+func (s *DecompressTestSuite) TestStreamToShouldDecompress() {
+	r, err := NewReader(s.writeSource("sample.txt.gz", s.compress(COMPRESSION_GZIP)), WithDecompression(DECOMPRESS_AUTO))
+	s.NoError(err)
+
+	var buf bytes.Buffer
+	n, err := r.StreamTo(&buf)
+	s.NoError(err)
+	s.Equal(int64(len(DECOMP_CONTENT)), n)
+	s.Equal(DECOMP_CONTENT, buf.String())
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed int
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed++
+	return nil
+}
+
+func (s *DecompressTestSuite) TestClosingDecoderShouldCloseOnceAtEndOfStream() {
+	recorder := &closeRecorder{Reader: strings.NewReader(DECOMP_CONTENT)}
+	decoder := &closingDecoder{ReadCloser: recorder}
+
+	data, err := io.ReadAll(decoder)
+	s.NoError(err)
+	s.Equal(DECOMP_CONTENT, string(data))
+	s.Equal(1, recorder.closed)
+
+	s.NoError(decoder.Close())
+	s.Equal(1, recorder.closed)
+}
+
+func (s *DecompressTestSuite) TestDecompressShouldWrapClosableDecoders() {
+	stream, format, err := decompress(bytes.NewReader(s.compress(COMPRESSION_ZSTD)), "", DECOMPRESS_AUTO)
+	s.NoError(err)
+	s.Equal(COMPRESSION_ZSTD, format)
+	s.IsType(&closingDecoder{}, stream)
+
+	data, err := io.ReadAll(stream)
+	s.NoError(err)
+	s.Equal(DECOMP_CONTENT, string(data))
+	s.True(stream.(*closingDecoder).closed)
+}
+
+func (s *DecompressTestSuite) TestDetectCompressionByMagicShouldNotMistakeText() {
+	s.Equal(COMPRESSION_NONE, detectCompressionByMagic([]byte("BZhello world")))
+	s.Equal(COMPRESSION_NONE, detectCompressionByMagic([]byte("BZh")))
+	s.Equal(COMPRESSION_BZIP2, detectCompressionByMagic([]byte("BZh91AY&SY")))
+	s.Equal(COMPRESSION_NONE, detectCompressionByMagic([]byte("x^2 + y")))
+	s.Equal(COMPRESSION_NONE, detectCompressionByMagic([]byte(FILE_LOCAL_CONTENT)))
+	s.Equal(COMPRESSION_ZLIB, detectCompressionByMagic([]byte{0x78, 0x9c, 0x00}))
+}
+
+func (s *DecompressTestSuite) TestStripCompressionExtension() {
+	s.Equal("archive.tar", StripCompressionExtension("archive.tgz", COMPRESSION_GZIP))
+	s.Equal("archive.tar", StripCompressionExtension("archive.tar.XZ", COMPRESSION_XZ))
+	s.Equal("archive.tar.gz", StripCompressionExtension("archive.tar.gz", COMPRESSION_ZSTD))
+	s.Equal(".gz", StripCompressionExtension(".gz", COMPRESSION_GZIP))
+}
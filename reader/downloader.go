@@ -0,0 +1,266 @@
+package reader
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Downloader fetches many sources concurrently through a bounded worker pool.
+// A failing source is reported in its result and does not stop the others.
+type Downloader struct {
+	options DownloaderOptions
+
+	mu        sync.Mutex
+	hostFreed *sync.Cond
+	active    map[string]int
+}
+
+type DownloadResult struct {
+	Source string
+	StreamResult
+	Duration time.Duration
+	Err      error
+}
+
+// downloadQueue holds the sources of one batch that no worker has taken yet.
+type downloadQueue struct {
+	pending []int
+	hosts   []string
+}
+
+func NewDownloader(opts ...DownloaderOption) *Downloader {
+	d := &Downloader{
+		options: newDownloaderOptions(opts),
+		active:  make(map[string]int),
+	}
+	d.hostFreed = sync.NewCond(&d.mu)
+	return d
+}
+
+func (d *Downloader) Download(destinationFolder string, sources []string) []DownloadResult {
+	return d.DownloadContext(context.Background(), destinationFolder, sources)
+}
+
+// DownloadContext downloads every source into destinationFolder and returns
+// one result per source, in the order given.
+func (d *Downloader) DownloadContext(ctx context.Context, destinationFolder string, sources []string) []DownloadResult {
+	results := make([]DownloadResult, len(sources))
+	progress := newBatchProgress(d.options.Progress, len(sources))
+
+	queue := &downloadQueue{
+		pending: make([]int, len(sources)),
+		hosts:   make([]string, len(sources)),
+	}
+	for i, source := range sources {
+		queue.pending[i] = i
+		queue.hosts[i] = sourceHost(source)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.options.Workers && i < len(sources); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				index, ok := d.next(queue)
+				if !ok {
+					return
+				}
+				results[index] = d.downloadOne(ctx, destinationFolder, sources[index], progress.item(index))
+				progress.settle(index)
+				d.release(queue.hosts[index])
+			}
+		}()
+	}
+	wg.Wait()
+
+	progress.finish()
+	return results
+}
+
+func (d *Downloader) downloadOne(ctx context.Context, destinationFolder, source string, progress ProgressReporter) (result DownloadResult) {
+	result.Source = source
+	started := time.Now()
+	defer func() {
+		result.Duration = time.Since(started)
+	}()
+
+	readerOptions := d.options.ReaderOptions
+	reporters := []ProgressReporter{progress}
+	if d.options.ItemProgress != nil {
+		reporters = append(reporters, d.options.ItemProgress(source))
+	}
+
+	var events *EventEmitter
+	if d.options.Events != nil {
+		events = d.options.Events.ForSource(source)
+		readerOptions = append(append([]Option{}, readerOptions...), func(o *Options) {
+			o.Retry.Notify = events.Retry
+		})
+		reporters = append(reporters, events)
+		defer func() {
+			events.Finish(result.StreamResult, result.Err)
+		}()
+	}
+
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	r, err := NewReaderContext(ctx, source, readerOptions...)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer r.Close()
+
+	if events != nil {
+		events.Start(r, r.saveName(newStreamOptions(d.options.StreamOptions)))
+	}
+
+	opts := append(append([]StreamOption{}, d.options.StreamOptions...), WithProgressReporter(multiReporter(reporters)))
+	result.StreamResult, result.Err = r.SaveContext(ctx, destinationFolder, opts...)
+	return result
+}
+
+// next takes the first pending source whose host is below the per-host
+// limit, so a busy host never holds up sources from other hosts. It waits
+// only while every pending source belongs to a busy host, and reports false
+// once nothing is pending.
+func (d *Downloader) next(queue *downloadQueue) (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for len(queue.pending) > 0 {
+		for i, index := range queue.pending {
+			host := queue.hosts[index]
+			if !d.hostAvailable(host) {
+				continue
+			}
+			queue.pending = append(queue.pending[:i], queue.pending[i+1:]...)
+			if host != "" {
+				d.active[host]++
+			}
+			return index, true
+		}
+		d.hostFreed.Wait()
+	}
+	return 0, false
+}
+
+func (d *Downloader) hostAvailable(host string) bool {
+	return d.options.PerHostLimit <= 0 || host == "" || d.active[host] < d.options.PerHostLimit
+}
+
+func (d *Downloader) release(host string) {
+	d.mu.Lock()
+	if host != "" {
+		d.active[host]--
+	}
+	d.mu.Unlock()
+	d.hostFreed.Broadcast()
+}
+
+func sourceHost(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == SCHEME_FILE {
+		return ""
+	}
+	return u.Host
+}
+
+type multiReporter []ProgressReporter
+
+func (m multiReporter) Report(event ProgressEvent) {
+	for _, r := range m {
+		if r != nil {
+			r.Report(event)
+		}
+	}
+}
+
+// batchProgress folds the progress of every item into a single stream.
+// The total is unknown while any unfinished item has not reported a size.
+type batchProgress struct {
+	reporter ProgressReporter
+	started  time.Time
+
+	mu    sync.Mutex
+	items []ProgressEvent
+}
+
+func newBatchProgress(reporter ProgressReporter, count int) *batchProgress {
+	items := make([]ProgressEvent, count)
+	for i := range items {
+		items[i].TotalBytes = -1
+	}
+	return &batchProgress{reporter: reporter, started: time.Now(), items: items}
+}
+
+func (b *batchProgress) item(index int) ProgressReporter {
+	return ProgressReporterFunc(func(event ProgressEvent) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.items[index] = event
+		b.report(PHASE_DOWNLOADING)
+	})
+}
+
+// settle marks an item that ended without reporting PHASE_DONE, because it
+// failed or was skipped, as finished with whatever bytes it got, so it no
+// longer leaves the batch total unknown or adds to the rate.
+func (b *batchProgress) settle(index int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	item := &b.items[index]
+	if item.Phase == PHASE_DONE {
+		return
+	}
+	item.Phase = PHASE_DONE
+	item.TotalBytes = item.BytesDone
+	item.Rate, item.AverageRate, item.ETA = 0, 0, 0
+	b.report(PHASE_DOWNLOADING)
+}
+
+func (b *batchProgress) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.report(PHASE_DONE)
+}
+
+func (b *batchProgress) report(phase ProgressPhase) {
+	if b.reporter == nil {
+		return
+	}
+
+	total := ProgressEvent{Phase: phase, Elapsed: time.Since(b.started), ETA: ETA_UNKNOWN}
+	unknown := false
+	for _, item := range b.items {
+		total.BytesDone += item.BytesDone
+		switch {
+		case item.TotalBytes > 0:
+			total.TotalBytes += item.TotalBytes
+		case item.Phase == PHASE_DONE:
+			total.TotalBytes += item.BytesDone
+		default:
+			unknown = true
+		}
+		if item.Phase != PHASE_DONE {
+			total.Rate += item.Rate
+			total.AverageRate += item.AverageRate
+		}
+	}
+	if unknown {
+		total.TotalBytes = -1
+	}
+
+	switch {
+	case phase == PHASE_DONE:
+		total.ETA = 0
+	case total.TotalBytes > 0 && total.AverageRate > 0:
+		total.ETA = time.Duration(float64(total.TotalBytes-total.BytesDone) / total.AverageRate * float64(time.Second))
+	}
+	b.reporter.Report(total)
+}
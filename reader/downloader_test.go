@@ -0,0 +1,282 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	BATCH_FILE_COUNT   = 8
+	BATCH_FILE_CONTENT = "batch file content"
+	BATCH_MISSING_PATH = "/missing.txt"
+	BATCH_SHORT_PATH   = "/short.txt"
+	BATCH_DELAY        = 20 * time.Millisecond
+	BATCH_STARVE_WAIT  = time.Second
+)
+
+type concurrencyTracker struct {
+	current atomic.Int32
+	max     atomic.Int32
+}
+
+func (c *concurrencyTracker) enter() {
+	n := c.current.Add(1)
+	for {
+		max := c.max.Load()
+		if n <= max || c.max.CompareAndSwap(max, n) {
+			return
+		}
+	}
+}
+
+func (c *concurrencyTracker) leave() {
+	c.current.Add(-1)
+}
+
+type DownloaderTestSuite struct {
+	suite.Suite
+	servers  []*httptest.Server
+	trackers []*concurrencyTracker
+	total    concurrencyTracker
+}
+
+func TestDownloaderTestSuite(t *testing.T) {
+	suite.Run(t, new(DownloaderTestSuite))
+}
+
+func (s *DownloaderTestSuite) SetupTest() {
+	s.servers = nil
+	s.trackers = nil
+	s.total = concurrencyTracker{}
+	for i := 0; i < 2; i++ {
+		tracker := &concurrencyTracker{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == BATCH_MISSING_PATH {
+				http.NotFound(w, r)
+				return
+			}
+			if r.URL.Path == BATCH_SHORT_PATH {
+				w.Header().Set("Content-Length", fmt.Sprint(2*len(BATCH_FILE_CONTENT)))
+				io.WriteString(w, BATCH_FILE_CONTENT)
+				return
+			}
+			if r.Method == http.MethodGet {
+				tracker.enter()
+				s.total.enter()
+				defer tracker.leave()
+				defer s.total.leave()
+				time.Sleep(BATCH_DELAY)
+			}
+			w.Header().Set("Content-Length", fmt.Sprint(len(BATCH_FILE_CONTENT)))
+			io.WriteString(w, BATCH_FILE_CONTENT)
+		}))
+		s.servers = append(s.servers, server)
+		s.trackers = append(s.trackers, tracker)
+	}
+}
+
+func (s *DownloaderTestSuite) TearDownTest() {
+	for _, server := range s.servers {
+		server.Close()
+	}
+}
+
+func (s *DownloaderTestSuite) sources(server *httptest.Server, count int) []string {
+	sources := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		sources = append(sources, fmt.Sprintf("%s/file-%d.txt", server.URL, i))
+	}
+	return sources
+}
+
+func (s *DownloaderTestSuite) TestShouldReturnResultPerSourceInOrder() {
+	sources := s.sources(s.servers[0], BATCH_FILE_COUNT)
+	dest := s.T().TempDir()
+
+	results := NewDownloader().Download(dest, sources)
+	s.Len(results, BATCH_FILE_COUNT)
+	for i, result := range results {
+		s.NoError(result.Err)
+		s.Equal(sources[i], result.Source)
+		s.Equal(filepath.Join(dest, fmt.Sprintf("file-%d.txt", i)), result.Path)
+		s.Equal(int64(len(BATCH_FILE_CONTENT)), result.Size)
+		s.Greater(result.Duration, time.Duration(0))
+	}
+}
+
+func (s *DownloaderTestSuite) TestShouldDownloadDuplicateSourcesSeparately() {
+	source := s.servers[0].URL + "/same.txt"
+	dest := s.T().TempDir()
+
+	results := NewDownloader(WithWorkers(2)).Download(dest, []string{source, source})
+	paths := map[string]bool{}
+	for _, result := range results {
+		s.Require().NoError(result.Err)
+		paths[result.Path] = true
+		data, err := os.ReadFile(result.Path)
+		s.NoError(err)
+		s.Equal(BATCH_FILE_CONTENT, string(data))
+	}
+	s.Len(paths, 2)
+
+	entries, err := os.ReadDir(dest)
+	s.NoError(err)
+	s.Len(entries, 2)
+}
+
+func (s *DownloaderTestSuite) TestFailureShouldNotAbortBatch() {
+	sources := []string{
+		s.servers[0].URL + "/first.txt",
+		s.servers[0].URL + BATCH_MISSING_PATH,
+		FILE_NOT_EXIST_SCHEME,
+		UNSUPPORTED_SCHEME_URL,
+		FILE_LOCAL_SCHEME,
+	}
+
+	results := NewDownloader(WithWorkers(2)).Download(s.T().TempDir(), sources)
+	s.NoError(results[0].Err)
+	s.Error(results[1].Err)
+	s.Error(results[2].Err)
+	s.Error(results[3].Err)
+	s.NoError(results[4].Err)
+
+	data, err := os.ReadFile(results[4].Path)
+	s.NoError(err)
+	s.Equal(FILE_LOCAL_CONTENT, string(data))
+}
+
+func (s *DownloaderTestSuite) TestShouldBoundWorkers() {
+	sources := append(s.sources(s.servers[0], BATCH_FILE_COUNT), s.sources(s.servers[1], BATCH_FILE_COUNT)...)
+
+	results := NewDownloader(WithWorkers(3)).Download(s.T().TempDir(), sources)
+	for _, result := range results {
+		s.NoError(result.Err)
+	}
+	s.LessOrEqual(s.total.max.Load(), int32(3))
+	s.Greater(s.total.max.Load(), int32(1))
+}
+
+func (s *DownloaderTestSuite) TestShouldApplyPerHostLimit() {
+	sources := append(s.sources(s.servers[0], BATCH_FILE_COUNT), s.sources(s.servers[1], BATCH_FILE_COUNT)...)
+
+	results := NewDownloader(WithWorkers(6), WithPerHostLimit(2)).Download(s.T().TempDir(), sources)
+	for _, result := range results {
+		s.NoError(result.Err)
+	}
+	for _, tracker := range s.trackers {
+		s.LessOrEqual(tracker.max.Load(), int32(2))
+	}
+}
+
+func (s *DownloaderTestSuite) TestPerHostLimitShouldNotStarveOtherHosts() {
+	served := make(chan struct{})
+	var once sync.Once
+	var starved atomic.Bool
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			select {
+			case <-served:
+			case <-time.After(BATCH_STARVE_WAIT):
+				starved.Store(true)
+			}
+		}
+		io.WriteString(w, BATCH_FILE_CONTENT)
+	}))
+	defer busy.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			once.Do(func() { close(served) })
+		}
+		io.WriteString(w, BATCH_FILE_CONTENT)
+	}))
+	defer other.Close()
+
+	sources := append(s.sources(busy, 4), other.URL+"/other.txt")
+	results := NewDownloader(WithWorkers(4), WithPerHostLimit(1)).Download(s.T().TempDir(), sources)
+	for _, result := range results {
+		s.NoError(result.Err)
+	}
+	s.False(starved.Load(), "a source on a free host waited behind a busy host")
+}
+
+func (s *DownloaderTestSuite) TestShouldAggregateProgress() {
+	sources := s.sources(s.servers[0], BATCH_FILE_COUNT)
+
+	var (
+		mu    sync.Mutex
+		last  ProgressEvent
+		items = make(map[string]bool)
+	)
+	batch := ProgressReporterFunc(func(e ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		last = e
+	})
+	item := func(source string) ProgressReporter {
+		return ProgressReporterFunc(func(ProgressEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			items[source] = true
+		})
+	}
+
+	NewDownloader(WithBatchProgress(batch), WithItemProgress(item)).Download(s.T().TempDir(), sources)
+
+	total := int64(BATCH_FILE_COUNT * len(BATCH_FILE_CONTENT))
+	s.Equal(PHASE_DONE, last.Phase)
+	s.Equal(total, last.BytesDone)
+	s.Equal(total, last.TotalBytes)
+	s.Len(items, BATCH_FILE_COUNT)
+}
+
+func (s *DownloaderTestSuite) TestFailedItemsShouldSettleAggregateProgress() {
+	sources := append(s.sources(s.servers[0], 2), s.servers[0].URL+BATCH_MISSING_PATH, s.servers[0].URL+BATCH_SHORT_PATH)
+
+	var (
+		mu     sync.Mutex
+		events []ProgressEvent
+	)
+	batch := ProgressReporterFunc(func(e ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+
+	results := NewDownloader(WithBatchProgress(batch), WithReaderOptions(WithRetry(RetryPolicy{MaxAttempts: 1}))).Download(s.T().TempDir(), sources)
+	s.Error(results[2].Err)
+	s.Error(results[3].Err)
+
+	// the last event before the batch finishes already accounts for every item
+	s.Require().GreaterOrEqual(len(events), 2)
+	settled := events[len(events)-2]
+	s.Equal(PHASE_DOWNLOADING, settled.Phase)
+	s.Equal(settled.BytesDone, settled.TotalBytes)
+	s.GreaterOrEqual(settled.BytesDone, int64(2*len(BATCH_FILE_CONTENT)))
+	s.Zero(settled.Rate)
+	s.Zero(settled.AverageRate)
+
+	last := events[len(events)-1]
+	s.Equal(PHASE_DONE, last.Phase)
+	s.Equal(last.BytesDone, last.TotalBytes)
+}
+
+func (s *DownloaderTestSuite) TestCancelShouldFailRemainingItems() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := NewDownloader().DownloadContext(ctx, s.T().TempDir(), s.sources(s.servers[0], BATCH_FILE_COUNT))
+	for _, result := range results {
+		s.ErrorIs(result.Err, context.Canceled)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !plan9
+
+package reader
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isTransientErrno reports whether err is a connection reset, refusal or
+// broken pipe.
+func isTransientErrno(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
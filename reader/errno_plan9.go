@@ -0,0 +1,7 @@
+package reader
+
+// isTransientErrno is always false on plan9, whose syscall package has no
+// errno constants; net.Error still covers network failures there.
+func isTransientErrno(err error) bool {
+	return false
+}
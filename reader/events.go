@@ -0,0 +1,183 @@
+package reader
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EVENT_START    EventType = "start"
+	EVENT_PROGRESS EventType = "progress"
+	EVENT_RETRY    EventType = "retry"
+	EVENT_VERIFY   EventType = "verify"
+	EVENT_FINISH   EventType = "finish"
+	EVENT_ERROR    EventType = "error"
+)
+
+var eventHeaders = []string{
+	HEADER_CONTENT_TYPE,
+	HEADER_CONTENT_ENCODING,
+	HEADER_CONTENT_DISPOSITION,
+	HEADER_ETAG,
+	HEADER_LAST_MODIFIED,
+	HEADER_ACCEPT_RANGES,
+}
+
+// Event is one line of NDJSON output. Only the fields relevant to Type are
+// set; durations and rates are in seconds and bytes per second. Size is
+// omitted when the source does not know its length.
+type Event struct {
+	Type   EventType `json:"type"`
+	Time   time.Time `json:"time"`
+	Source string    `json:"source,omitempty"`
+
+	Filename string            `json:"filename,omitempty"`
+	Size     *int64            `json:"size,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+
+	Phase       ProgressPhase `json:"phase,omitempty"`
+	BytesDone   int64         `json:"bytes_done,omitempty"`
+	TotalBytes  int64         `json:"total_bytes,omitempty"`
+	Rate        float64       `json:"rate,omitempty"`
+	AverageRate float64       `json:"average_rate,omitempty"`
+	ETA         *float64      `json:"eta,omitempty"`
+	Elapsed     float64       `json:"elapsed,omitempty"`
+
+	Attempt int     `json:"attempt,omitempty"`
+	Delay   float64 `json:"delay,omitempty"`
+
+	Algorithms []ChecksumAlgorithm `json:"algorithms,omitempty"`
+	Verified   *bool               `json:"verified,omitempty"`
+
+	Path            string         `json:"path,omitempty"`
+	Action          FinalizeAction `json:"action,omitempty"`
+	FilenameAltered bool           `json:"filename_altered,omitempty"`
+	Error           string         `json:"error,omitempty"`
+}
+
+// EventEmitter writes the lifecycle of one download as NDJSON. It is a
+// ProgressReporter and its Retry method fits RetryPolicy.Notify. Writes are
+// serialized so several emitters may share one writer.
+type EventEmitter struct {
+	source string
+	out    *lockedEncoder
+	now    func() time.Time
+}
+
+type lockedEncoder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewEventEmitter(w io.Writer, source string) *EventEmitter {
+	return &EventEmitter{
+		source: source,
+		out:    &lockedEncoder{enc: json.NewEncoder(w)},
+		now:    time.Now,
+	}
+}
+
+// ForSource returns an emitter for another download sharing the same output.
+func (e *EventEmitter) ForSource(source string) *EventEmitter {
+	return &EventEmitter{source: source, out: e.out, now: e.now}
+}
+
+// Start reports the probed source before any data is copied. filename is the
+// name the download will be saved under, before sanitizing.
+func (e *EventEmitter) Start(r *Reader, filename string) {
+	name, _ := SanitizeFilename(filename)
+	event := Event{Type: EVENT_START, Filename: name}
+	if size := r.TotalSize(); size >= 0 {
+		event.Size = int64Ptr(size)
+	}
+	if header := r.Header(); header != nil {
+		event.Headers = interestingHeaders(header)
+	}
+	e.emit(event)
+}
+
+func (e *EventEmitter) Report(p ProgressEvent) {
+	event := Event{
+		Type:        EVENT_PROGRESS,
+		Phase:       p.Phase,
+		BytesDone:   p.BytesDone,
+		TotalBytes:  p.TotalBytes,
+		Rate:        p.Rate,
+		AverageRate: p.AverageRate,
+		Elapsed:     p.Elapsed.Seconds(),
+	}
+	if p.ETA >= 0 {
+		eta := p.ETA.Seconds()
+		event.ETA = &eta
+	}
+	e.emit(event)
+}
+
+func (e *EventEmitter) Retry(attempt int, delay time.Duration, err error) {
+	e.emit(Event{Type: EVENT_RETRY, Attempt: attempt, Delay: delay.Seconds(), Error: err.Error()})
+}
+
+// Finish reports the outcome of a download: a verify event when checksums
+// were checked, then either finish or error.
+func (e *EventEmitter) Finish(result StreamResult, err error) {
+	var mismatch *ChecksumMismatchError
+	switch {
+	case errors.As(err, &mismatch):
+		e.emit(Event{Type: EVENT_VERIFY, Algorithms: []ChecksumAlgorithm{mismatch.Algorithm}, Verified: boolPtr(false)})
+	case err == nil && len(result.Verified) > 0:
+		e.emit(Event{Type: EVENT_VERIFY, Algorithms: result.Verified, Verified: boolPtr(true)})
+	}
+
+	if err != nil {
+		e.emit(Event{Type: EVENT_ERROR, Path: result.Path, Error: err.Error()})
+		return
+	}
+	e.emit(Event{
+		Type:            EVENT_FINISH,
+		Path:            result.Path,
+		Size:            int64Ptr(result.Size),
+		Action:          result.Action,
+		FilenameAltered: result.FilenameAltered,
+	})
+}
+
+// Error reports a failure that happened before a download could start.
+func (e *EventEmitter) Error(err error) {
+	e.emit(Event{Type: EVENT_ERROR, Error: err.Error()})
+}
+
+func (e *EventEmitter) emit(event Event) {
+	event.Time = e.now().UTC()
+	event.Source = e.source
+
+	e.out.mu.Lock()
+	defer e.out.mu.Unlock()
+	e.out.enc.Encode(event)
+}
+
+func interestingHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string)
+	for _, name := range eventHeaders {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func int64Ptr(n int64) *int64 {
+	return &n
+}
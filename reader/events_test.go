@@ -0,0 +1,175 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	EVENTS_FILE_NAME    = "events.txt"
+	EVENTS_FILE_PATH    = "/" + EVENTS_FILE_NAME
+	EVENTS_FLAKY_PATH   = "/flaky.txt"
+	EVENTS_EMPTY_PATH   = "/empty.txt"
+	EVENTS_CHUNKED_PATH = "/chunked.txt"
+	EVENTS_RENAMED      = "renamed.txt"
+	EVENTS_ETAG         = `"events-v1"`
+)
+
+type EventsTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	out      bytes.Buffer
+	failures atomic.Int32
+}
+
+func TestEventsTestSuite(t *testing.T) {
+	suite.Run(t, new(EventsTestSuite))
+}
+
+func (s *EventsTestSuite) SetupTest() {
+	s.out.Reset()
+	s.failures.Store(1)
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case EVENTS_FLAKY_PATH:
+			if s.failures.Add(-1) >= 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fallthrough
+		case EVENTS_EMPTY_PATH:
+			w.Header().Set("Content-Length", "0")
+		case EVENTS_CHUNKED_PATH:
+			w.(http.Flusher).Flush()
+			io.WriteString(w, FILE_LOCAL_CONTENT)
+		case EVENTS_FILE_PATH:
+			w.Header().Set("ETag", EVENTS_ETAG)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("X-Internal", "not reported")
+			io.WriteString(w, FILE_LOCAL_CONTENT)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func (s *EventsTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *EventsTestSuite) events() []Event {
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(s.out.Bytes()))
+	for scanner.Scan() {
+		var event Event
+		s.Require().NoError(json.Unmarshal(scanner.Bytes(), &event), scanner.Text())
+		events = append(events, event)
+	}
+	return events
+}
+
+func (s *EventsTestSuite) types(events []Event) []EventType {
+	var types []EventType
+	for _, e := range events {
+		if len(types) == 0 || types[len(types)-1] != e.Type {
+			types = append(types, e.Type)
+		}
+	}
+	return types
+}
+
+func (s *EventsTestSuite) download(path string, opts ...StreamOption) []Event {
+	source := s.server.URL + path
+	emitter := NewEventEmitter(&s.out, source)
+	retry := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Notify: emitter.Retry}
+
+	r, err := NewReader(source, WithRetry(retry))
+	s.Require().NoError(err)
+	emitter.Start(r, r.saveName(newStreamOptions(opts)))
+
+	result, err := r.Save(s.T().TempDir(), append(opts, WithProgressReporter(emitter))...)
+	emitter.Finish(result, err)
+	return s.events()
+}
+
+func (s *EventsTestSuite) TestShouldEmitLifecycleAsNDJSON() {
+	sum := sha256.Sum256([]byte(FILE_LOCAL_CONTENT))
+	events := s.download(EVENTS_FILE_PATH, WithChecksum(CHECKSUM_SHA256, hexDigest(sum[:])))
+
+	s.Equal([]EventType{EVENT_START, EVENT_PROGRESS, EVENT_VERIFY, EVENT_FINISH}, s.types(events))
+	for _, e := range events {
+		s.Equal(s.server.URL+EVENTS_FILE_PATH, e.Source)
+		s.False(e.Time.IsZero())
+	}
+
+	start := events[0]
+	s.Equal(EVENTS_FILE_NAME, start.Filename)
+	s.Equal(int64(FILE_LOCAL_SIZE), *start.Size)
+	s.Equal(EVENTS_ETAG, start.Headers[HEADER_ETAG])
+	s.Equal("text/plain", start.Headers[HEADER_CONTENT_TYPE])
+	s.NotContains(start.Headers, "X-Internal")
+
+	verify := events[len(events)-2]
+	s.Equal([]ChecksumAlgorithm{CHECKSUM_SHA256}, verify.Algorithms)
+	s.True(*verify.Verified)
+
+	finish := events[len(events)-1]
+	s.Equal(int64(FILE_LOCAL_SIZE), *finish.Size)
+	s.Equal(ACTION_CREATED, finish.Action)
+	s.NotEmpty(finish.Path)
+}
+
+func (s *EventsTestSuite) TestStartShouldReportOverriddenFilename() {
+	events := s.download(EVENTS_FILE_PATH, WithFilename(EVENTS_RENAMED))
+
+	s.Equal(EVENT_START, events[0].Type)
+	s.Equal(EVENTS_RENAMED, events[0].Filename)
+}
+
+func (s *EventsTestSuite) TestStartShouldTellEmptyFromUnknownSize() {
+	empty := s.download(EVENTS_EMPTY_PATH)
+	s.Require().NotNil(empty[0].Size)
+	s.Zero(*empty[0].Size)
+	s.Zero(*empty[len(empty)-1].Size)
+
+	s.out.Reset()
+	chunked := s.download(EVENTS_CHUNKED_PATH)
+	s.Nil(chunked[0].Size)
+	s.Equal(int64(FILE_LOCAL_SIZE), *chunked[len(chunked)-1].Size)
+}
+
+func (s *EventsTestSuite) TestShouldEmitRetryEvents() {
+	events := s.download(EVENTS_FLAKY_PATH)
+
+	s.Equal(EVENT_RETRY, events[0].Type)
+	s.Equal(1, events[0].Attempt)
+	s.NotEmpty(events[0].Error)
+	s.Equal(EVENT_FINISH, events[len(events)-1].Type)
+}
+
+func (s *EventsTestSuite) TestShouldEmitFailedVerifyAndError() {
+	events := s.download(EVENTS_FILE_PATH, WithChecksum(CHECKSUM_SHA256, CHECKSUM_WRONG_DIGEST))
+
+	types := s.types(events)
+	s.Equal([]EventType{EVENT_VERIFY, EVENT_ERROR}, types[len(types)-2:])
+	s.False(*events[len(events)-2].Verified)
+	s.Contains(events[len(events)-1].Error, "checksum mismatch")
+}
+
+func (s *EventsTestSuite) TestProgressEventShouldOmitUnknownETA() {
+	emitter := NewEventEmitter(&s.out, FILE_LOCAL_SCHEME)
+	emitter.Report(ProgressEvent{Phase: PHASE_DOWNLOADING, BytesDone: 1, ETA: ETA_UNKNOWN})
+
+	s.NotContains(s.out.String(), `"eta"`)
+	s.Contains(s.out.String(), `"bytes_done":1`)
+}
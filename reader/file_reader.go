@@ -1,27 +1,47 @@
 package reader
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
 	apperrors "abc/errors"
 )
 
-func NewFileReader(source string) (*FileReader, error) {
-	totalSize, exists := isFileExist(source)
-	if !exists {
-		return nil, errors.New(apperrors.ERR_FILE_NOT_FOUND)
+func NewFileReader(source string, opts ...Option) (*FileReader, error) {
+	return newFileReader(source, newOptions(opts))
+}
+
+func newFileReader(source string, options Options) (*FileReader, error) {
+	info, err := os.Stat(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &apperrors.SourceNotFoundError{Path: source, Err: err}
+	}
+	if err != nil {
+		return nil, err
 	}
 	filename := filepath.Base(source)
-	return &FileReader{src: source, filename: filename, totalSize: totalSize}, nil
+	return &FileReader{
+		ctx:       options.Context,
+		src:       source,
+		filename:  filename,
+		totalSize: info.Size(),
+		modTime:   info.ModTime().UnixNano(),
+	}, nil
 }
 
 type FileReader struct {
+	ctx       context.Context
 	src       string
 	filename  string
 	file      *os.File
 	totalSize int64
+	modTime   int64
 }
 
 func (r *FileReader) Filename() string {
@@ -32,7 +52,34 @@ func (r *FileReader) TotalSize() int64 {
 	return r.totalSize
 }
 
+// Stat sniffs the first bytes of the file for a compression signature.
+func (r *FileReader) Stat() SourceInfo {
+	info := SourceInfo{
+		URL:          SCHEME_FILE_PREFIX + r.src,
+		Filename:     r.filename,
+		Size:         r.totalSize,
+		LastModified: time.Unix(0, r.modTime),
+		AcceptRanges: true,
+	}
+	if abs, err := filepath.Abs(r.src); err == nil {
+		info.URL = SCHEME_FILE_PREFIX + abs
+	}
+
+	file, err := os.Open(r.src)
+	if err != nil {
+		return info
+	}
+	defer file.Close()
+	header := make([]byte, COMPRESSION_MAGIC_SIZE)
+	n, _ := io.ReadFull(file, header)
+	info.Compression = detectCompressionByMagic(header[:n])
+	return info
+}
+
 func (r *FileReader) Read(p []byte) (int, error) {
+	if err := orBackground(r.ctx).Err(); err != nil {
+		return 0, err
+	}
 	if r.file == nil {
 		file, err := os.Open(r.src)
 		if err != nil {
@@ -43,10 +90,37 @@ func (r *FileReader) Read(p []byte) (int, error) {
 	return r.file.Read(p)
 }
 
-func isFileExist(path string) (int64, bool) {
-	info, err := os.Stat(path)
+func (r *FileReader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *FileReader) SetContext(ctx context.Context) {
+	r.ctx = ctx
+}
+
+func (r *FileReader) Validator() string {
+	return fmt.Sprintf("%d-%d", r.totalSize, r.modTime)
+}
+
+func (r *FileReader) ResumeFrom(offset int64) (int64, error) {
+	r.Close()
+	if offset <= 0 || offset > r.totalSize {
+		return 0, nil
+	}
+
+	file, err := os.Open(r.src)
 	if err != nil {
-		return 0, false
+		return 0, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return 0, err
 	}
-	return info.Size(), true
+	r.file = file
+	return offset, nil
 }
@@ -2,8 +2,11 @@ package reader
 
 import (
 	"io"
+	"io/fs"
 	"testing"
 
+	apperrors "abc/errors"
+
 	"github.com/stretchr/testify/suite"
 )
 
@@ -37,7 +40,8 @@ func (s *FileReaderTestSuite) TestNewFileReaderShouldReturnErrorIfFileDoesNotExi
 	r, err := NewFileReader(FR_NONEXISTENT_PATH)
 	s.Error(err)
 	s.Nil(r)
-	s.Equal("file not found", err.Error())
+	s.ErrorIs(err, apperrors.ErrFileNotFound)
+	s.ErrorIs(err, fs.ErrNotExist)
 }
 
 func (s *FileReaderTestSuite) TestFilenameShouldReturnCorrectFilename() {
@@ -74,3 +78,29 @@ func (s *FileReaderTestSuite) TestReadShouldReturnErrorIfFileDoesNotExist() {
 	s.Error(err)
 	s.Equal(FR_NONEXISTENT_OPEN_ERROR, err.Error())
 }
+
+func (s *FileReaderTestSuite) TestResumeFromShouldSeekToOffset() {
+	r, err := NewFileReader(FR_FILE_LOCAL_REL_PATH)
+	s.NoError(err)
+
+	offset, err := r.ResumeFrom(7)
+	s.NoError(err)
+	s.Equal(int64(7), offset)
+
+	bytes, err := io.ReadAll(r)
+	s.NoError(err)
+	s.Equal(FR_FILE_LOCAL_CONTENT[7:], string(bytes))
+}
+
+func (s *FileReaderTestSuite) TestResumeFromShouldRestartIfOffsetBeyondSize() {
+	r, err := NewFileReader(FR_FILE_LOCAL_REL_PATH)
+	s.NoError(err)
+
+	offset, err := r.ResumeFrom(FR_FILE_LOCAL_SIZE + 1)
+	s.NoError(err)
+	s.Equal(int64(0), offset)
+
+	bytes, err := io.ReadAll(r)
+	s.NoError(err)
+	s.Equal(FR_FILE_LOCAL_CONTENT, string(bytes))
+}
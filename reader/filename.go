@@ -0,0 +1,202 @@
+package reader
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	DEFAULT_FILENAME = "download"
+
+	MEDIA_TYPE_OCTET_STREAM = "application/octet-stream"
+
+	DISPOSITION_PARAM_FILENAME     = "filename"
+	DISPOSITION_PARAM_FILENAME_EXT = "filename*"
+)
+
+var preferredExtensions = map[string]string{
+	"application/gzip":            ".gz",
+	"application/json":            ".json",
+	"application/pdf":             ".pdf",
+	"application/x-bzip2":         ".bz2",
+	"application/x-gzip":          ".gz",
+	"application/x-iso9660-image": ".iso",
+	"application/x-tar":           ".tar",
+	"application/x-xz":            ".xz",
+	"application/xml":             ".xml",
+	"application/zip":             ".zip",
+	"application/zstd":            ".zst",
+	"image/jpeg":                  ".jpg",
+	"text/csv":                    ".csv",
+	"text/html":                   ".html",
+	"text/plain":                  ".txt",
+	"text/xml":                    ".xml",
+}
+
+// responseFilename picks the name a response should be saved under: the
+// Content-Disposition filename if present, otherwise the last segment of the
+// final URL after redirects, with an extension inferred from Content-Type
+// when the name has none.
+func responseFilename(resp *http.Response, source string) string {
+	name := parseContentDisposition(resp.Header.Get(HEADER_CONTENT_DISPOSITION))
+	if name != "" {
+		return name
+	}
+
+	var u *url.URL
+	if resp.Request != nil {
+		u = resp.Request.URL
+	}
+	if u == nil {
+		parsed, err := url.Parse(source)
+		if err != nil {
+			return DEFAULT_FILENAME
+		}
+		u = parsed
+	}
+
+	name = urlFilename(u)
+	if path.Ext(name) == "" {
+		name += extensionForContentType(resp.Header.Get(HEADER_CONTENT_TYPE))
+	}
+	return name
+}
+
+func urlFilename(u *url.URL) string {
+	name := path.Base(u.Path)
+	if name == "" || name == "." || name == "/" {
+		return DEFAULT_FILENAME
+	}
+	return name
+}
+
+func extensionForContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" || mediaType == MEDIA_TYPE_OCTET_STREAM {
+		return ""
+	}
+	if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+	exts, err := mime.ExtensionsByType(mediaType)
+	if err != nil || len(exts) == 0 {
+		return ""
+	}
+	return exts[0]
+}
+
+// parseContentDisposition returns the filename from an RFC 6266 header,
+// preferring the RFC 5987 encoded filename* over the plain one. Headers that
+// mime.ParseMediaType rejects, such as unquoted names with spaces or
+// ISO-8859-1 encoded values, go through a lenient parser instead.
+func parseContentDisposition(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	if _, params, err := mime.ParseMediaType(header); err == nil {
+		if name := params[DISPOSITION_PARAM_FILENAME]; name != "" {
+			return name
+		}
+	}
+
+	var plain, extended string
+	for _, part := range splitDispositionParams(header) {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case DISPOSITION_PARAM_FILENAME:
+			plain = unquoteDispositionValue(strings.TrimSpace(value))
+		case DISPOSITION_PARAM_FILENAME_EXT:
+			extended = decodeExtValue(strings.TrimSpace(value))
+		}
+	}
+
+	if extended != "" {
+		return extended
+	}
+	return plain
+}
+
+func splitDispositionParams(header string) []string {
+	var (
+		parts   []string
+		current strings.Builder
+		quoted  bool
+		escaped bool
+	)
+	for _, c := range header {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ';' && !quoted:
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteRune(c)
+	}
+	return append(parts, strings.TrimSpace(current.String()))
+}
+
+func unquoteDispositionValue(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	value = value[1 : len(value)-1]
+
+	var b strings.Builder
+	escaped := false
+	for _, c := range value {
+		if c == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// decodeExtValue decodes an RFC 5987 ext-value: charset'language'pct-encoded.
+func decodeExtValue(value string) string {
+	charset, rest, ok := strings.Cut(value, "'")
+	if !ok {
+		return ""
+	}
+	_, encoded, ok := strings.Cut(rest, "'")
+	if !ok {
+		return ""
+	}
+
+	decoded, err := url.PathUnescape(encoded)
+	if err != nil {
+		return ""
+	}
+
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii":
+		if !utf8.ValidString(decoded) {
+			return ""
+		}
+		return decoded
+	case "iso-8859-1":
+		runes := make([]rune, 0, len(decoded))
+		for i := 0; i < len(decoded); i++ {
+			runes = append(runes, rune(decoded[i]))
+		}
+		return string(runes)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,80 @@
+package reader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseContentDisposition(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: `attachment; filename=plain.txt`, want: "plain.txt"},
+		{header: `attachment; filename="quoted name.txt"`, want: "quoted name.txt"},
+		{header: `inline; filename="inline.pdf"`, want: "inline.pdf"},
+		{header: `attachment; filename="EURO rates.txt"; filename*=UTF-8''%e2%82%ac%20rates.txt`, want: "€ rates.txt"},
+		{header: `attachment; filename*=UTF-8''%e2%82%ac%20rates.txt; filename="EURO rates.txt"`, want: "€ rates.txt"},
+		{header: `attachment; filename*=iso-8859-1'en'%A3%20rates.txt`, want: "£ rates.txt"},
+		{header: `attachment; filename=unquoted with spaces.txt`, want: "unquoted with spaces.txt"},
+		{header: `attachment; filename="semi;colon \"quote\".txt"`, want: `semi;colon "quote".txt`},
+		{header: `attachment`, want: ""},
+		{header: ``, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseContentDisposition(tt.header); got != tt.want {
+			t.Fatalf("header %q: want %q got %q", tt.header, tt.want, got)
+		}
+	}
+}
+
+func TestExtensionForContentType(t *testing.T) {
+	tests := map[string]string{
+		"text/plain; charset=utf-8": ".txt",
+		"application/pdf":           ".pdf",
+		"application/gzip":          ".gz",
+		"application/octet-stream":  "",
+		"":                          "",
+		"not a media type":          "",
+	}
+	for contentType, want := range tests {
+		if got := extensionForContentType(contentType); got != want {
+			t.Fatalf("content type %q: want %q got %q", contentType, want, got)
+		}
+	}
+}
+
+func TestResponseFilename_FollowsRedirectsAndInfersExtension(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download":
+			http.Redirect(w, r, "/files/quarterly%20report?token=secret", http.StatusFound)
+		case "/files/quarterly report":
+			w.Header().Set("Content-Type", "application/pdf")
+		case "/named":
+			w.Header().Set("Content-Disposition", `attachment; filename*=UTF-8''na%C3%AFve.csv`)
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := map[string]string{
+		"/download?id=42": "quarterly report.pdf",
+		"/named?x=1":      "naïve.csv",
+		"/":               DEFAULT_FILENAME + ".html",
+	}
+	for path, want := range tests {
+		r, err := NewHTTPReader(server.URL + path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+		if got := r.Filename(); got != want {
+			t.Fatalf("%s: want %q got %q", path, want, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package reader
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// claimFinalPath moves tempPath to finalPath, or to the first numbered
+// variant of it that is free, without ever replacing an existing file. The
+// name is claimed by the rename itself so concurrent downloads of the same
+// name cannot overwrite each other.
+func claimFinalPath(tempPath, finalPath string) (string, error) {
+	path := finalPath
+	for count := 1; ; count++ {
+		err := renameNoReplace(tempPath, path)
+		if err == nil {
+			return path, syncDir(filepath.Dir(path))
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		path = numberedFilePath(finalPath, count)
+	}
+}
+
+// linkRename claims newPath with a hard link, which fails if the name is
+// taken, then drops oldPath. Filesystems without hard links fall back to
+// reserving the name with O_EXCL and renaming over the reservation.
+func linkRename(oldPath, newPath string) error {
+	err := os.Link(oldPath, newPath)
+	if err == nil {
+		return os.Remove(oldPath)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return err
+	}
+
+	placeholder, err := os.OpenFile(newPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	placeholder.Close()
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		os.Remove(newPath)
+		return err
+	}
+	return nil
+}
+
+// syncDir flushes a directory entry so a completed rename survives a crash.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
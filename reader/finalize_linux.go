@@ -0,0 +1,18 @@
+//go:build linux
+
+package reader
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+func renameNoReplace(oldPath, newPath string) error {
+	err := unix.Renameat2(unix.AT_FDCWD, oldPath, unix.AT_FDCWD, newPath, unix.RENAME_NOREPLACE)
+	if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EINVAL) {
+		// kernel or filesystem without RENAME_NOREPLACE
+		return linkRename(oldPath, newPath)
+	}
+	return err
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package reader
+
+func renameNoReplace(oldPath, newPath string) error {
+	return linkRename(oldPath, newPath)
+}
@@ -0,0 +1,107 @@
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+const (
+	FINALIZE_FILE_NAME = "final.txt"
+	FINALIZE_EXISTING  = "existing content"
+	FINALIZE_WORKERS   = 8
+)
+
+func writeTempFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	f, err := os.CreateTemp(dir, "*"+PART_FILE_SUFFIX)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return f.Name()
+}
+
+func TestRenameNoReplace_ShouldNotClobberExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, FINALIZE_FILE_NAME)
+	if err := os.WriteFile(target, []byte(FINALIZE_EXISTING), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	temp := writeTempFile(t, dir, FILE_LOCAL_CONTENT)
+
+	if err := renameNoReplace(temp, target); !os.IsExist(err) {
+		t.Fatalf("expected exist error, got %v", err)
+	}
+	if err := linkRename(temp, target); !os.IsExist(err) {
+		t.Fatalf("expected exist error from link fallback, got %v", err)
+	}
+
+	data, _ := os.ReadFile(target)
+	if string(data) != FINALIZE_EXISTING {
+		t.Fatalf("existing file was overwritten: %q", data)
+	}
+	if _, err := os.Stat(temp); err != nil {
+		t.Fatalf("expected temp file to be left in place: %v", err)
+	}
+}
+
+func TestLinkRename_ShouldMoveFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, FINALIZE_FILE_NAME)
+	temp := writeTempFile(t, dir, FILE_LOCAL_CONTENT)
+
+	if err := linkRename(temp, target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != FILE_LOCAL_CONTENT {
+		t.Fatalf("expected %q, got %q", FILE_LOCAL_CONTENT, data)
+	}
+	if _, err := os.Stat(temp); !os.IsNotExist(err) {
+		t.Fatalf("expected temp file to be gone")
+	}
+}
+
+func TestClaimFinalPath_ConcurrentFinalizationsGetDistinctNames(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, FINALIZE_FILE_NAME)
+
+	temps := make([]string, FINALIZE_WORKERS)
+	for i := range temps {
+		temps[i] = writeTempFile(t, dir, fmt.Sprint(i))
+	}
+
+	var wg sync.WaitGroup
+	paths := make([]string, FINALIZE_WORKERS)
+	errs := make([]error, FINALIZE_WORKERS)
+	for i := range temps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], errs[i] = claimFinalPath(temps[i], target)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for i, path := range paths {
+		if errs[i] != nil {
+			t.Fatalf("unexpected error: %v", errs[i])
+		}
+		if seen[path] {
+			t.Fatalf("path %q claimed twice", path)
+		}
+		seen[path] = true
+
+		data, _ := os.ReadFile(path)
+		if string(data) != fmt.Sprint(i) {
+			t.Fatalf("expected %q at %q, got %q", fmt.Sprint(i), path, data)
+		}
+	}
+}
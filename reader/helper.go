@@ -8,11 +8,6 @@ import (
 )
 
 func GetUniqueFilePath(originalPath string) (string, error) {
-	dir := filepath.Dir(originalPath)
-	base := filepath.Base(originalPath)
-	ext := filepath.Ext(base)
-	name := strings.TrimSuffix(base, ext)
-
 	path := originalPath
 	count := 1
 
@@ -25,33 +20,57 @@ func GetUniqueFilePath(originalPath string) (string, error) {
 			return "", err
 		}
 
-		newName := fmt.Sprintf("%s_%d%s", name, count, ext)
-		path = filepath.Join(dir, newName)
+		path = numberedFilePath(originalPath, count)
 		count++
 	}
 }
 
-func HumanizeReadableSize(bytes int64) (float64, string) {
-	const (
-		_          = iota
-		KB float64 = 1 << (10 * iota)
-		MB
-		GB
-		TB
-	)
+func numberedFilePath(originalPath string, count int) string {
+	dir := filepath.Dir(originalPath)
+	base := filepath.Base(originalPath)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	return filepath.Join(dir, fmt.Sprintf("%s_%d%s", name, count, ext))
+}
+
+type SizeUnits int
+
+const (
+	// SIZE_UNITS_BINARY divides by 1024 but keeps the short KB, MB labels.
+	SIZE_UNITS_BINARY SizeUnits = iota
+	SIZE_UNITS_IEC
+	SIZE_UNITS_SI
+)
+
+var sizeUnitLabels = map[SizeUnits][]string{
+	SIZE_UNITS_BINARY: {"bytes", "KB", "MB", "GB", "TB"},
+	SIZE_UNITS_IEC:    {"B", "KiB", "MiB", "GiB", "TiB"},
+	SIZE_UNITS_SI:     {"B", "kB", "MB", "GB", "TB"},
+}
+
+func HumanizeReadableSize(bytes int64, units ...SizeUnits) (float64, string) {
+	unit := SIZE_UNITS_BINARY
+	if len(units) > 0 {
+		unit = units[0]
+	}
+	labels, ok := sizeUnitLabels[unit]
+	if !ok {
+		labels = sizeUnitLabels[SIZE_UNITS_BINARY]
+	}
+
+	base := 1024.0
+	if unit == SIZE_UNITS_SI {
+		base = 1000
+	}
+
 	val := float64(bytes)
-	switch {
-	case val >= TB:
-		return val / TB, "TB"
-	case val >= GB:
-		return val / GB, "GB"
-	case val >= MB:
-		return val / MB, "MB"
-	case val >= KB:
-		return val / KB, "KB"
-	default:
-		return val, "bytes"
+	i := 0
+	for i < len(labels)-1 && val >= base {
+		val /= base
+		i++
 	}
+	return val, labels[i]
 }
 
 func NotifyProgress(n int64, totalSize int64) {
@@ -59,12 +78,12 @@ func NotifyProgress(n int64, totalSize int64) {
 	if totalSize > 0 {
 		totalVal, totalUnit := HumanizeReadableSize(totalSize)
 		percent := float64(n) / float64(totalSize) * 100
-		fmt.Printf("\rDownloaded %.2f %s / %.2f %s (%.2f%%)...", dlVal, dlUnit, totalVal, totalUnit, percent)
+		fmt.Fprintf(os.Stderr, "\rDownloaded %.2f %s / %.2f %s (%.2f%%)...", dlVal, dlUnit, totalVal, totalUnit, percent)
 	} else {
 		if dlUnit == "bytes" {
-			fmt.Printf("\rDownloaded %.0f bytes...", dlVal)
+			fmt.Fprintf(os.Stderr, "\rDownloaded %.0f bytes...", dlVal)
 		} else {
-			fmt.Printf("\rDownloaded %.2f %s...", dlVal, dlUnit)
+			fmt.Fprintf(os.Stderr, "\rDownloaded %.2f %s...", dlVal, dlUnit)
 		}
 	}
 }
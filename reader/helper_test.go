@@ -104,23 +104,63 @@ func TestHumanizeReadableSize(t *testing.T) {
 	}
 }
 
-func captureStdout(f func()) string {
-	orig := os.Stdout
+func TestHumanizeReadableSize_Units(t *testing.T) {
+	type tc struct {
+		bytes    int64
+		units    SizeUnits
+		wantVal  float64
+		wantUnit string
+	}
+	tests := []tc{
+		{bytes: 999, units: SIZE_UNITS_SI, wantVal: 999, wantUnit: "B"},
+		{bytes: 1500, units: SIZE_UNITS_SI, wantVal: 1.5, wantUnit: "kB"},
+		{bytes: 2_000_000, units: SIZE_UNITS_SI, wantVal: 2, wantUnit: "MB"},
+		{bytes: 1000, units: SIZE_UNITS_IEC, wantVal: 1000, wantUnit: "B"},
+		{bytes: 1536, units: SIZE_UNITS_IEC, wantVal: 1.5, wantUnit: "KiB"},
+		{bytes: 1 << 30, units: SIZE_UNITS_IEC, wantVal: 1, wantUnit: "GiB"},
+		{bytes: 1 << 20, units: SIZE_UNITS_BINARY, wantVal: 1, wantUnit: "MB"},
+	}
+
+	for _, tt := range tests {
+		gotVal, gotUnit := HumanizeReadableSize(tt.bytes, tt.units)
+		if gotUnit != tt.wantUnit || gotVal != tt.wantVal {
+			t.Fatalf("bytes=%d units=%d: want %v %q got %v %q", tt.bytes, tt.units, tt.wantVal, tt.wantUnit, gotVal, gotUnit)
+		}
+	}
+}
+
+func captureStderr(f func()) string {
+	orig := os.Stderr
 	r, w, _ := os.Pipe()
-	os.Stdout = w
+	os.Stderr = w
 
 	f()
 
 	_ = w.Close()
-	os.Stdout = orig
+	os.Stderr = orig
 	var buf bytes.Buffer
 	_, _ = io.Copy(&buf, r)
 	_ = r.Close()
 	return buf.String()
 }
 
+// readOnlyFile opens a file that rejects every write, standing in for a
+// part file on a full or failing disk.
+func readOnlyFile(t *testing.T) *os.File {
+	path := filepath.Join(t.TempDir(), "read-only")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
 func TestNotifyProgress_WithTotalSize(t *testing.T) {
-	out := captureStdout(func() {
+	out := captureStderr(func() {
 		NotifyProgress(512, 1024)
 	})
 	if !strings.Contains(out, "Downloaded") {
@@ -135,7 +175,7 @@ func TestNotifyProgress_WithTotalSize(t *testing.T) {
 }
 
 func TestNotifyProgress_WithoutTotalSize_Bytes(t *testing.T) {
-	out := captureStdout(func() {
+	out := captureStderr(func() {
 		NotifyProgress(5, 0)
 	})
 	if !strings.Contains(out, "Downloaded 5 bytes...") {
@@ -144,7 +184,7 @@ func TestNotifyProgress_WithoutTotalSize_Bytes(t *testing.T) {
 }
 
 func TestNotifyProgress_WithoutTotalSize_KB(t *testing.T) {
-	out := captureStdout(func() {
+	out := captureStderr(func() {
 		NotifyProgress(2048, 0)
 	})
 	if !strings.Contains(out, "Downloaded 2.00 KB") {
@@ -1,50 +1,112 @@
 package reader
 
 import (
-	"compress/gzip"
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	apperrors "abc/errors"
 )
 
-func NewHTTPReader(source string) (*HTTPReader, error) {
+const (
+	HEADER_RANGE               = "Range"
+	HEADER_IF_RANGE            = "If-Range"
+	HEADER_CONTENT_RANGE       = "Content-Range"
+	HEADER_ACCEPT_RANGES       = "Accept-Ranges"
+	HEADER_ETAG                = "ETag"
+	HEADER_LAST_MODIFIED       = "Last-Modified"
+	HEADER_CONTENT_TYPE        = "Content-Type"
+	HEADER_CONTENT_DISPOSITION = "Content-Disposition"
+	HEADER_CONTENT_ENCODING    = "Content-Encoding"
+
+	ACCEPT_RANGES_BYTES = "bytes"
+	WEAK_ETAG_PREFIX    = "W/"
+)
+
+func NewHTTPReader(source string, opts ...Option) (*HTTPReader, error) {
+	return newHTTPReader(source, newOptions(opts))
+}
+
+func newHTTPReader(source string, options Options) (*HTTPReader, error) {
+	ctx := orBackground(options.Context)
 	httpClient := &http.Client{
-		Timeout: 10 * time.Second,
+		Transport: options.Transport,
+		Timeout:   10 * time.Second,
 	}
 
-	filename, totalSize, err := getUrlInfo(httpClient, source)
+	info, err := getUrlInfo(ctx, httpClient, source, options.Retry)
 	if err != nil {
 		return nil, err
 	}
 
+	var checksums []Checksum
+	if options.DiscoverChecksums {
+		checksums = discoverChecksums(ctx, httpClient, source, info.header)
+	}
+
+	transport := options.Transport
+	if transport == nil {
+		transport = newDefaultTransport()
+	}
 	httpClientForGET := &http.Client{
-		Transport: &http.Transport{
-			ResponseHeaderTimeout: 15 * time.Second,
-			IdleConnTimeout:       90 * time.Second,
-			DisableKeepAlives:     false,
-		},
+		Transport: transport,
 	}
 
 	return &HTTPReader{
-		src:       source,
-		filename:  filename,
-		client:    httpClientForGET,
-		totalSize: totalSize,
+		ctx:          ctx,
+		src:          source,
+		filename:     info.filename,
+		client:       httpClientForGET,
+		totalSize:    info.totalSize,
+		contentType:  info.contentType,
+		etag:         info.etag,
+		lastModified: info.lastModified,
+		acceptRanges: info.acceptRanges,
+		encoded:      info.encoded,
+		header:       info.header,
+		finalURL:     info.finalURL,
+		segments:     options.Segments,
+		retry:        options.Retry,
+		checksums:    checksums,
 	}, nil
 }
 
 type HTTPReader struct {
-	src       string
-	client    *http.Client
-	body      io.ReadCloser
-	filename  string
-	totalSize int64
+	ctx          context.Context
+	src          string
+	client       *http.Client
+	body         io.ReadCloser
+	filename     string
+	totalSize    int64
+	contentType  string
+	etag         string
+	lastModified string
+	acceptRanges bool
+	segments     int
+	retry        RetryPolicy
+	offset       int64
+	resumes      int
+	wire         int64
+	encoded      bool
+	header       http.Header
+	finalURL     string
+	checksums    []Checksum
+}
+
+type urlInfo struct {
+	filename     string
+	totalSize    int64
+	contentType  string
+	etag         string
+	lastModified string
+	acceptRanges bool
+	encoded      bool
+	header       http.Header
+	finalURL     string
 }
 
 func (r *HTTPReader) Filename() string {
@@ -55,53 +117,317 @@ func (r *HTTPReader) TotalSize() int64 {
 	return r.totalSize
 }
 
+// Header returns the response headers of the initial probe.
+func (r *HTTPReader) Header() http.Header {
+	return r.header
+}
+
+func (r *HTTPReader) Stat() SourceInfo {
+	return SourceInfo{
+		URL:             r.finalURL,
+		Filename:        r.filename,
+		Size:            r.totalSize,
+		ContentType:     r.contentType,
+		LastModified:    parseHTTPTime(r.lastModified),
+		ETag:            r.etag,
+		AcceptRanges:    r.acceptRanges,
+		Compression:     compressionForContentType(r.contentType),
+		ContentEncoding: r.header.Get(HEADER_CONTENT_ENCODING),
+	}
+}
+
+func (r *HTTPReader) WireBytes() int64 {
+	return r.wire
+}
+
+func (r *HTTPReader) Checksums() []Checksum {
+	return r.checksums
+}
+
 func (r *HTTPReader) Read(p []byte) (int, error) {
 	if r.body == nil {
-		resp, err := r.client.Get(r.src)
-		if err != nil {
+		if err := r.open(); err != nil {
 			return 0, err
 		}
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return 0, errors.New(apperrors.ERR_URL_NOT_EXISTS)
+	for {
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+		r.resumes++
+		if !r.canResumeBody(err) || !r.retry.wait(orBackground(r.ctx), r.resumes, err) {
+			return n, err
 		}
 
-		ctype := resp.Header.Get("Content-Type")
-		fmt.Println("ctype", ctype)
-		if strings.Contains(ctype, "application/gzip") || strings.Contains(ctype, "application/x-gzip") {
-			gz, err := gzip.NewReader(resp.Body)
-			if err != nil {
-				resp.Body.Close()
-				return 0, err
-			}
-			r.filename = gz.Name
-			r.body = gz
-		} else {
-			r.body = resp.Body
+		if resumeErr := r.resumeBody(); resumeErr != nil {
+			return n, resumeErr
+		}
+		if n > 0 {
+			return n, nil
 		}
 	}
-	return r.body.Read(p)
 }
 
-func getUrlInfo(client *http.Client, url string) (string, int64, error) {
-	resp, err := client.Head(url)
+func (r *HTTPReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+func (r *HTTPReader) SetContext(ctx context.Context) {
+	r.ctx = ctx
+}
+
+func (r *HTTPReader) Validator() string {
+	if r.etag != "" && !strings.HasPrefix(r.etag, WEAK_ETAG_PREFIX) {
+		return r.etag
+	}
+	return r.lastModified
+}
+
+// ResumeFrom reports the offset the server honoured: offset on 206, 0 when it
+// ignored the range or the If-Range validator no longer matched.
+func (r *HTTPReader) ResumeFrom(offset int64) (int64, error) {
+	r.Close()
+
+	r.wire = 0
+	r.resumes = 0
+	validator := r.Validator()
+	if offset <= 0 || validator == "" || r.encoded ||
+		(r.totalSize > 0 && offset > r.totalSize) {
+		return 0, nil
+	}
+
+	if r.totalSize > 0 && offset == r.totalSize {
+		r.body = http.NoBody
+		return offset, nil
+	}
+
+	resp, err := r.do(fmt.Sprintf("bytes=%d-", offset))
+	if err != nil {
+		return 0, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, ok := parseContentRangeStart(resp.Header.Get(HEADER_CONTENT_RANGE)); !ok || start != offset {
+			resp.Body.Close()
+			return 0, nil
+		}
+		if err := r.setBody(resp); err != nil {
+			return 0, err
+		}
+		r.offset = offset
+		return offset, nil
+
+	case http.StatusOK:
+		if err := r.setBody(resp); err != nil {
+			return 0, err
+		}
+		return 0, nil
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return 0, nil
+
+	default:
+		resp.Body.Close()
+		return 0, newStatusError(resp)
+	}
+}
+
+func (r *HTTPReader) SegmentCount() int {
+	if r.segments <= 1 || !r.acceptRanges || r.totalSize <= 0 || r.encoded {
+		return 0
+	}
+	return r.segments
+}
+
+func (r *HTTPReader) OpenRange(start, end int64) (io.ReadCloser, error) {
+	resp, err := r.do(fmt.Sprintf("bytes=%d-%d", start, end))
 	if err != nil {
-		resp, err = client.Get(url)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, apperrors.ErrRangeNotSatisfied
+	}
+	if rangeStart, ok := parseContentRangeStart(resp.Header.Get(HEADER_CONTENT_RANGE)); !ok || rangeStart != start {
+		resp.Body.Close()
+		return nil, apperrors.ErrRangeNotSatisfied
+	}
+	return resp.Body, nil
+}
+
+func (r *HTTPReader) open() error {
+	resp, err := r.do("")
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return newStatusError(resp)
+	}
+
+	r.offset = 0
+	r.wire = 0
+	r.resumes = 0
+	return r.setBody(resp)
+}
+
+// canResumeBody counts every body that failed in this transfer against
+// MaxAttempts, so a server that keeps dropping the connection is given up on.
+func (r *HTTPReader) canResumeBody(err error) bool {
+	return !r.encoded && r.Validator() != "" && r.retry.retryable(orBackground(r.ctx), r.resumes, err)
+}
+
+func (r *HTTPReader) resumeBody() error {
+	r.Close()
+
+	resp, err := r.do(fmt.Sprintf("bytes=%d-", r.offset))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return apperrors.ErrRangeNotSatisfied
+	}
+	if start, ok := parseContentRangeStart(resp.Header.Get(HEADER_CONTENT_RANGE)); !ok || start != r.offset {
+		resp.Body.Close()
+		return apperrors.ErrRangeNotSatisfied
+	}
+
+	return r.setBody(resp)
+}
+
+func (r *HTTPReader) do(byteRange string) (*http.Response, error) {
+	var resp *http.Response
+	err := r.retry.do(orBackground(r.ctx), func() error {
+		var err error
+		resp, err = r.getRange(byteRange)
 		if err != nil {
-			return "", 0, err
+			return err
+		}
+		if se := newStatusError(resp); se.Retryable() {
+			resp.Body.Close()
+			return se
+		}
+		return nil
+	})
+	return resp, err
+}
+
+func (r *HTTPReader) getRange(byteRange string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(orBackground(r.ctx), http.MethodGet, r.src, nil)
+	if err != nil {
+		return nil, err
+	}
+	// ranges address the identity representation, so only full transfers
+	// may be content-encoded
+	if byteRange != "" {
+		req.Header.Set(HEADER_ACCEPT_ENCODING, ENCODING_IDENTITY)
+		req.Header.Set(HEADER_RANGE, byteRange)
+		if validator := r.Validator(); validator != "" {
+			req.Header.Set(HEADER_IF_RANGE, validator)
 		}
+	} else {
+		req.Header.Set(HEADER_ACCEPT_ENCODING, HTTP_ACCEPT_ENCODING)
+	}
+	return r.client.Do(req)
+}
+
+func (r *HTTPReader) setBody(resp *http.Response) error {
+	debugLog.Printf("%s: %s content type %q", r.src, resp.Status, resp.Header.Get(HEADER_CONTENT_TYPE))
+
+	contentEncoding := resp.Header.Get(HEADER_CONTENT_ENCODING)
+	body, err := decodeContentEncoding(resp.Body, contentEncoding, &r.wire)
+	if err != nil {
+		resp.Body.Close()
+		return err
+	}
+	r.encoded = r.encoded || isContentEncoded(contentEncoding)
+	r.body = body
+	return nil
+}
+
+func parseContentRangeStart(contentRange string) (int64, bool) {
+	// Content-Range: bytes <start>-<end>/<size>
+	spec, ok := strings.CutPrefix(contentRange, ACCEPT_RANGES_BYTES+" ")
+	if !ok {
+		return 0, false
+	}
+	startStr, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return start, true
+}
+
+func getUrlInfo(ctx context.Context, client *http.Client, url string, policy RetryPolicy) (urlInfo, error) {
+	var info urlInfo
+	err := policy.do(ctx, func() error {
+		var err error
+		info, err = probeUrl(ctx, client, url)
+		return err
+	})
+	return info, err
+}
+
+func probeUrl(ctx context.Context, client *http.Client, url string) (urlInfo, error) {
+	header := http.Header{HEADER_ACCEPT_ENCODING: {HTTP_ACCEPT_ENCODING}}
+	resp, err := doRequest(ctx, client, http.MethodHead, url, header)
+	if err != nil && ctx.Err() == nil {
+		resp, err = doRequest(ctx, client, http.MethodGet, url, header)
+	}
+	if err != nil {
+		return urlInfo{}, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return "", 0, errors.New(apperrors.ERR_URL_NOT_EXISTS)
+		return urlInfo{}, newStatusError(resp)
 	}
 
-	filename := resp.Header.Get("Content-Disposition")
-	filename = strings.TrimPrefix(filename, "attachment; filename=")
-	if filename == "" {
-		filename = filepath.Base(url)
+	return urlInfo{
+		filename:     responseFilename(resp, url),
+		totalSize:    resp.ContentLength,
+		contentType:  resp.Header.Get(HEADER_CONTENT_TYPE),
+		etag:         resp.Header.Get(HEADER_ETAG),
+		lastModified: resp.Header.Get(HEADER_LAST_MODIFIED),
+		acceptRanges: resp.Header.Get(HEADER_ACCEPT_RANGES) == ACCEPT_RANGES_BYTES,
+		encoded:      isContentEncoded(resp.Header.Get(HEADER_CONTENT_ENCODING)),
+		header:       resp.Header,
+		finalURL:     resp.Request.URL.String(),
+	}, nil
+}
+
+func newDefaultTransport() http.RoundTripper {
+	return &http.Transport{
+		ResponseHeaderTimeout: 15 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		DisableKeepAlives:     false,
 	}
+}
 
-	fileSize := resp.ContentLength
-	return filename, fileSize, nil
+func doRequest(ctx context.Context, client *http.Client, method string, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	return client.Do(req)
 }
@@ -1,12 +1,16 @@
 package reader
 
 import (
+	"bytes"
 	"compress/gzip"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
+	apperrors "abc/errors"
+
 	"github.com/stretchr/testify/suite"
 )
 
@@ -27,6 +31,9 @@ const (
 	GET_OK_GZIP_FILE_PATH = SLASH + GET_OK_GZIP_FILE_NAME
 	GZIP_FILE_CONTENT     = "This is a simple text file from gzip for testing purposes.."
 
+	GZIP_MEDIA_FILE_NAME = "archive.gz"
+	GZIP_MEDIA_FILE_PATH = SLASH + GZIP_MEDIA_FILE_NAME // application/gzip without Content-Encoding
+
 	DOES_NOT_EXIST_FILE_PATH = SLASH + "does-not-exist.txt"
 )
 
@@ -48,6 +55,7 @@ func (s *HTTPReaderTestSuite) SetupTest() {
 
 		getOKConditions := (r.Method == http.MethodGet && r.URL.Path == GET_OK_FILE_PATH)
 		getOKGZIPConditions := (r.Method == http.MethodGet && r.URL.Path == GET_OK_GZIP_FILE_PATH)
+		gzipMediaConditions := r.URL.Path == GZIP_MEDIA_FILE_PATH
 
 		getNotAllowedConditions := (r.Method == http.MethodGet && r.URL.Path == HEAD_OK_FILE_PATH)
 
@@ -63,12 +71,21 @@ func (s *HTTPReaderTestSuite) SetupTest() {
 			io.WriteString(w, GET_FILE_CONTENT)
 
 		case getOKGZIPConditions:
-			w.WriteHeader(http.StatusOK)
 			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(http.StatusOK)
 			gz := gzip.NewWriter(w)
 			defer gz.Close()
 			io.WriteString(gz, GZIP_FILE_CONTENT)
 
+		case gzipMediaConditions:
+			body := gzipBytes(GZIP_FILE_CONTENT)
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodGet {
+				w.Write(body)
+			}
+
 		case hiJackConditions:
 			hj, ok := w.(http.Hijacker)
 			if !ok {
@@ -110,7 +127,7 @@ func (s *HTTPReaderTestSuite) TestNewHTTPReaderShouldReturnErrorIfURLDoesNotExis
 	r, err := NewHTTPReader(url)
 	s.Error(err)
 	s.Nil(r)
-	s.Equal("url not exists", err.Error())
+	s.ErrorIs(err, apperrors.ErrURLNotExists)
 }
 
 func (s *HTTPReaderTestSuite) TestNewHTTPReaderShouldReturnErrorIfNetworkReset() {
@@ -183,5 +200,83 @@ func (s *HTTPReaderTestSuite) TestReadShouldReturnErrorIfGetReturnsNotOK() {
 	bytes, err := io.ReadAll(r)
 	s.Error(err)
 	s.Equal(0, len(bytes))
-	s.Equal("url not exists", err.Error())
+	var se *apperrors.HTTPStatusError
+	s.ErrorAs(err, &se)
+	s.Equal(http.StatusForbidden, se.Code)
+	s.False(IsRetryable(err))
+}
+
+func gzipBytes(content string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	io.WriteString(gz, content)
+	gz.Close()
+	return buf.Bytes()
+}
+
+func (s *HTTPReaderTestSuite) TestReadShouldKeepGzipMediaTypeAsIs() {
+	url := s.server.URL + GZIP_MEDIA_FILE_PATH
+	r, err := NewHTTPReader(url)
+	s.NoError(err)
+
+	bytes, err := io.ReadAll(r)
+	s.NoError(err)
+	s.Equal(gzipBytes(GZIP_FILE_CONTENT), bytes)
+	s.Equal(GZIP_MEDIA_FILE_NAME, r.Filename())
+	s.Equal(int64(len(bytes)), r.TotalSize())
+}
+
+func (s *HTTPReaderTestSuite) TestReadShouldDecompressGzipMediaTypeWhenRequested() {
+	url := s.server.URL + GZIP_MEDIA_FILE_PATH
+	r, err := NewReader(url, WithDecompression(DECOMPRESS_AUTO))
+	s.NoError(err)
+
+	bytes, err := io.ReadAll(r)
+	s.NoError(err)
+	s.Equal(GZIP_FILE_CONTENT, string(bytes))
+	s.Equal("archive", r.Filename())
+}
+
+func (s *HTTPReaderTestSuite) TestReadShouldCountWireBytesForContentEncoding() {
+	url := s.server.URL + GET_OK_GZIP_FILE_PATH
+	r, err := NewHTTPReader(url)
+	s.NoError(err)
+
+	var progress int64
+	pr := &ProgressReader{
+		Reader: r,
+		Wire:   r,
+		Notify: func(n int64, totalSize int64) {
+			progress = n
+		},
+	}
+
+	bytes, err := io.ReadAll(pr)
+	s.NoError(err)
+	s.Equal(GZIP_FILE_CONTENT, string(bytes))
+	s.Equal(int64(len(gzipBytes(GZIP_FILE_CONTENT))), progress)
+	s.Equal(progress, r.WireBytes())
+}
+
+func (s *HTTPReaderTestSuite) TestDecodeContentEncodingShouldRejectUnknownCoding() {
+	var wire int64
+	_, err := decodeContentEncoding(io.NopCloser(bytes.NewReader(nil)), "compress", &wire)
+	s.Error(err)
+	s.Equal("unsupported content encoding", err.Error())
+}
+
+func (s *HTTPReaderTestSuite) TestReadShouldWriteDebugOutputOnlyWhenEnabled() {
+	var debug bytes.Buffer
+	SetDebugOutput(&debug)
+	defer SetDebugOutput(io.Discard)
+
+	r, err := NewHTTPReader(s.server.URL + GZIP_MEDIA_FILE_PATH)
+	s.NoError(err)
+
+	out := captureStderr(func() {
+		_, err = io.ReadAll(r)
+	})
+	s.NoError(err)
+	s.Empty(out)
+	s.Contains(debug.String(), `content type "application/gzip"`)
 }
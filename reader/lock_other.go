@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package reader
+
+import "os"
+
+// lockFile cannot lock on this platform, so part files are not guarded
+// against concurrent transfers.
+func lockFile(f *os.File) (bool, error) {
+	return true, nil
+}
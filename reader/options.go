@@ -0,0 +1,227 @@
+package reader
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+type Options struct {
+	Context   context.Context
+	Segments  int
+	Retry     RetryPolicy
+	Transport http.RoundTripper
+	S3        S3Config
+
+	DiscoverChecksums bool
+	Decompression     DecompressionMode
+	Stdin             io.Reader
+}
+
+type Option func(*Options)
+
+func WithSegments(n int) Option {
+	return func(o *Options) {
+		o.Segments = n
+	}
+}
+
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *Options) {
+		o.Retry = policy
+	}
+}
+
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *Options) {
+		o.Transport = transport
+	}
+}
+
+func WithS3Config(config S3Config) Option {
+	return func(o *Options) {
+		o.S3 = config
+	}
+}
+
+func WithChecksumDiscovery() Option {
+	return func(o *Options) {
+		o.DiscoverChecksums = true
+	}
+}
+
+func WithDecompression(mode DecompressionMode) Option {
+	return func(o *Options) {
+		o.Decompression = mode
+	}
+}
+
+// WithStdin makes the stdin source read from r instead of os.Stdin.
+func WithStdin(r io.Reader) Option {
+	return func(o *Options) {
+		o.Stdin = r
+	}
+}
+
+func newOptions(opts []Option) Options {
+	o := Options{Context: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+type PartFilePolicy int
+
+const (
+	PART_FILE_KEEP PartFilePolicy = iota
+	PART_FILE_REMOVE_ON_CANCEL
+	PART_FILE_REMOVE_ON_ERROR
+)
+
+type StreamOptions struct {
+	PartFilePolicy   PartFilePolicy
+	Checksums        []Checksum
+	CollisionPolicy  CollisionPolicy
+	Progress         ProgressReporter
+	ProgressThrottle ProgressThrottle
+	RateLimiters     []*RateLimiter
+	Filename         string
+}
+
+type StreamOption func(*StreamOptions)
+
+func WithPartFilePolicy(policy PartFilePolicy) StreamOption {
+	return func(o *StreamOptions) {
+		o.PartFilePolicy = policy
+	}
+}
+
+func WithChecksum(algorithm ChecksumAlgorithm, expected string) StreamOption {
+	return func(o *StreamOptions) {
+		o.Checksums = append(o.Checksums, Checksum{Algorithm: algorithm, Expected: expected})
+	}
+}
+
+func WithCollisionPolicy(policy CollisionPolicy) StreamOption {
+	return func(o *StreamOptions) {
+		o.CollisionPolicy = policy
+	}
+}
+
+// WithProgressReporter sends structured progress events to reporter, by
+// default at most every PROGRESS_DEFAULT_INTERVAL.
+func WithProgressReporter(reporter ProgressReporter) StreamOption {
+	return func(o *StreamOptions) {
+		o.Progress = reporter
+	}
+}
+
+func WithProgressThrottle(throttle ProgressThrottle) StreamOption {
+	return func(o *StreamOptions) {
+		o.ProgressThrottle = throttle
+	}
+}
+
+// WithRateLimit caps this download at bytesPerSecond, on top of the global
+// limit.
+func WithRateLimit(bytesPerSecond int64) StreamOption {
+	return WithRateLimiter(NewRateLimiter(bytesPerSecond))
+}
+
+// WithRateLimiter throttles the download by limiter, which may be shared with
+// other downloads and adjusted while they run.
+func WithRateLimiter(limiter *RateLimiter) StreamOption {
+	return func(o *StreamOptions) {
+		o.RateLimiters = append(o.RateLimiters, limiter)
+	}
+}
+
+// WithFilename saves the download under name instead of the name offered by
+// the source. It is sanitized like any other name.
+func WithFilename(name string) StreamOption {
+	return func(o *StreamOptions) {
+		o.Filename = name
+	}
+}
+
+func newStreamOptions(opts []StreamOption) StreamOptions {
+	o := StreamOptions{ProgressThrottle: ProgressThrottle{Interval: PROGRESS_DEFAULT_INTERVAL}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+const DEFAULT_DOWNLOAD_WORKERS = 4
+
+type DownloaderOptions struct {
+	Workers       int
+	PerHostLimit  int
+	ReaderOptions []Option
+	StreamOptions []StreamOption
+	Progress      ProgressReporter
+	ItemProgress  func(source string) ProgressReporter
+	Events        *EventEmitter
+}
+
+type DownloaderOption func(*DownloaderOptions)
+
+func WithWorkers(n int) DownloaderOption {
+	return func(o *DownloaderOptions) {
+		o.Workers = n
+	}
+}
+
+// WithPerHostLimit caps concurrent downloads from a single host. Zero means
+// only the worker count applies.
+func WithPerHostLimit(n int) DownloaderOption {
+	return func(o *DownloaderOptions) {
+		o.PerHostLimit = n
+	}
+}
+
+func WithReaderOptions(opts ...Option) DownloaderOption {
+	return func(o *DownloaderOptions) {
+		o.ReaderOptions = append(o.ReaderOptions, opts...)
+	}
+}
+
+func WithStreamOptions(opts ...StreamOption) DownloaderOption {
+	return func(o *DownloaderOptions) {
+		o.StreamOptions = append(o.StreamOptions, opts...)
+	}
+}
+
+// WithBatchProgress reports progress aggregated over the whole batch.
+func WithBatchProgress(reporter ProgressReporter) DownloaderOption {
+	return func(o *DownloaderOptions) {
+		o.Progress = reporter
+	}
+}
+
+// WithItemProgress creates a reporter for every download in the batch.
+func WithItemProgress(factory func(source string) ProgressReporter) DownloaderOption {
+	return func(o *DownloaderOptions) {
+		o.ItemProgress = factory
+	}
+}
+
+// WithEvents writes start, progress, retry, verify and finish events for every
+// download in the batch.
+func WithEvents(emitter *EventEmitter) DownloaderOption {
+	return func(o *DownloaderOptions) {
+		o.Events = emitter
+	}
+}
+
+func newDownloaderOptions(opts []DownloaderOption) DownloaderOptions {
+	o := DownloaderOptions{Workers: DEFAULT_DOWNLOAD_WORKERS}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.Workers < 1 {
+		o.Workers = 1
+	}
+	return o
+}
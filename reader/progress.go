@@ -0,0 +1,137 @@
+package reader
+
+import "time"
+
+type ProgressPhase string
+
+const (
+	PHASE_DOWNLOADING ProgressPhase = "downloading"
+	PHASE_VERIFYING   ProgressPhase = "verifying"
+	PHASE_DONE        ProgressPhase = "done"
+
+	PROGRESS_DEFAULT_INTERVAL = 250 * time.Millisecond
+	PROGRESS_SMOOTHING        = 0.3
+
+	ETA_UNKNOWN time.Duration = -1
+)
+
+// ProgressEvent is a snapshot of a transfer. Rate is the throughput since the
+// previous event and AverageRate an exponentially smoothed one, both in bytes
+// per second. TotalBytes is not positive and ETA is ETA_UNKNOWN when the size
+// is not known.
+type ProgressEvent struct {
+	Phase       ProgressPhase
+	BytesDone   int64
+	TotalBytes  int64
+	Rate        float64
+	AverageRate float64
+	ETA         time.Duration
+	Elapsed     time.Duration
+}
+
+func (e ProgressEvent) Percent() float64 {
+	if e.TotalBytes <= 0 {
+		return 0
+	}
+	return float64(e.BytesDone) / float64(e.TotalBytes) * 100
+}
+
+type ProgressReporter interface {
+	Report(event ProgressEvent)
+}
+
+type ProgressReporterFunc func(event ProgressEvent)
+
+func (f ProgressReporterFunc) Report(event ProgressEvent) {
+	f(event)
+}
+
+// ProgressThrottle limits how often a reporter is called: an event is emitted
+// once Interval has passed or the transfer advanced by Percent points since
+// the last one. Zero fields disable that trigger, and a zero throttle reports
+// every read. Phase changes and completion are always reported.
+type ProgressThrottle struct {
+	Interval time.Duration
+	Percent  float64
+}
+
+type progressTracker struct {
+	now func() time.Time
+
+	started   time.Time
+	startSize int64
+	phase     ProgressPhase
+
+	emitted     bool
+	lastEvent   time.Time
+	lastSize    int64
+	rate        float64
+	averageRate float64
+}
+
+func (t *progressTracker) begin(size int64) {
+	if !t.started.IsZero() {
+		return
+	}
+	if t.now == nil {
+		t.now = time.Now
+	}
+	t.started = t.now()
+	t.startSize = size
+	t.lastEvent = t.started
+	t.lastSize = size
+	if t.phase == "" {
+		t.phase = PHASE_DOWNLOADING
+	}
+}
+
+func (t *progressTracker) due(throttle ProgressThrottle, now time.Time, size, total int64) bool {
+	if !t.emitted {
+		return true
+	}
+	if total > 0 && size >= total && t.lastSize < total {
+		return true
+	}
+	if throttle.Interval <= 0 && throttle.Percent <= 0 {
+		return true
+	}
+	if throttle.Interval > 0 && now.Sub(t.lastEvent) >= throttle.Interval {
+		return true
+	}
+	if throttle.Percent > 0 && total > 0 {
+		advanced := float64(size-t.lastSize) / float64(total) * 100
+		return advanced >= throttle.Percent
+	}
+	return false
+}
+
+func (t *progressTracker) event(now time.Time, size, total int64) ProgressEvent {
+	if elapsed := now.Sub(t.lastEvent).Seconds(); elapsed > 0 {
+		t.rate = float64(size-t.lastSize) / elapsed
+		if t.averageRate == 0 {
+			t.averageRate = t.rate
+		} else {
+			t.averageRate = PROGRESS_SMOOTHING*t.rate + (1-PROGRESS_SMOOTHING)*t.averageRate
+		}
+	}
+	t.emitted = true
+	t.lastEvent = now
+	t.lastSize = size
+
+	eta := ETA_UNKNOWN
+	if total > 0 && size >= total {
+		eta = 0
+	} else if total > 0 && t.averageRate > 0 {
+		eta = time.Duration(float64(total-size) / t.averageRate * float64(time.Second))
+	}
+
+	return ProgressEvent{
+		Phase:       t.phase,
+		BytesDone:   size,
+		TotalBytes:  total,
+		Rate:        t.rate,
+		AverageRate: t.averageRate,
+		ETA:         eta,
+		Elapsed:     now.Sub(t.started),
+	}
+}
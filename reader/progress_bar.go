@@ -0,0 +1,212 @@
+package reader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	PROGRESS_BAR_DEFAULT_WIDTH = 80
+	PROGRESS_BAR_MIN_WIDTH     = 10
+	PROGRESS_LOG_INTERVAL      = 5 * time.Second
+
+	ENV_COLUMNS = "COLUMNS"
+
+	ANSI_CLEAR_LINE = "\x1b[2K"
+	ANSI_CURSOR_UP  = "\x1b[%dA"
+)
+
+// ProgressRenderer draws progress bars for one or more downloads. On a
+// terminal the bars are stacked and redrawn in place; any other writer gets a
+// plain log line per download at most every LogInterval and on phase changes.
+type ProgressRenderer struct {
+	Units       SizeUnits
+	LogInterval time.Duration
+
+	out   io.Writer
+	tty   bool
+	width func() int
+	now   func() time.Time
+
+	mu    sync.Mutex
+	bars  []*ProgressBar
+	drawn int
+}
+
+func NewProgressRenderer(out io.Writer) *ProgressRenderer {
+	r := &ProgressRenderer{
+		Units:       SIZE_UNITS_IEC,
+		LogInterval: PROGRESS_LOG_INTERVAL,
+		out:         out,
+		width:       func() int { return PROGRESS_BAR_DEFAULT_WIDTH },
+		now:         time.Now,
+	}
+	if f, ok := out.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		r.tty = true
+		r.width = func() int { return terminalWidth(f) }
+	}
+	return r
+}
+
+func (r *ProgressRenderer) IsTerminal() bool {
+	return r.tty
+}
+
+// Bar adds a download labelled label below the existing ones. The returned
+// bar is a ProgressReporter to pass to WithProgressReporter.
+func (r *ProgressRenderer) Bar(label string) *ProgressBar {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar := &ProgressBar{renderer: r, label: label, event: ProgressEvent{ETA: ETA_UNKNOWN}}
+	r.bars = append(r.bars, bar)
+	return bar
+}
+
+type ProgressBar struct {
+	renderer *ProgressRenderer
+	label    string
+	event    ProgressEvent
+	logged   bool
+	lastLog  time.Time
+}
+
+func (b *ProgressBar) Report(event ProgressEvent) {
+	r := b.renderer
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	phaseChanged := event.Phase != b.event.Phase
+	b.event = event
+	if r.tty {
+		r.redraw()
+		return
+	}
+
+	now := r.now()
+	if b.logged && !phaseChanged && now.Sub(b.lastLog) < r.LogInterval {
+		return
+	}
+	b.logged = true
+	b.lastLog = now
+	fmt.Fprintln(r.out, b.logLine(r.Units))
+}
+
+func (r *ProgressRenderer) redraw() {
+	var sb strings.Builder
+	if r.drawn > 0 {
+		fmt.Fprintf(&sb, ANSI_CURSOR_UP, r.drawn)
+	}
+
+	// one column short of the width so a full line never wraps
+	width := r.width() - 1
+	for _, bar := range r.bars {
+		sb.WriteString("\r" + ANSI_CLEAR_LINE)
+		sb.WriteString(bar.barLine(width, r.Units))
+		sb.WriteString("\n")
+	}
+	r.drawn = len(r.bars)
+	io.WriteString(r.out, sb.String())
+}
+
+func (b *ProgressBar) barLine(width int, units SizeUnits) string {
+	e := b.event
+	label := truncateText(b.label, width/3)
+
+	var stats string
+	if e.TotalBytes > 0 {
+		stats = fmt.Sprintf(" %5.1f%%", e.Percent())
+	} else {
+		stats = " " + formatSize(e.BytesDone, units)
+	}
+	stats += "  " + formatRate(e.AverageRate, units) + "  " + phaseStatus(e)
+
+	barWidth := width - utf8.RuneCountInString(label) - utf8.RuneCountInString(stats) - 3
+	if e.TotalBytes <= 0 || barWidth < PROGRESS_BAR_MIN_WIDTH {
+		return truncateText(label+stats, width)
+	}
+
+	filled := int(float64(barWidth) * e.Percent() / 100)
+	if filled > barWidth {
+		filled = barWidth
+	}
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+	return label + " [" + bar + "]" + stats
+}
+
+func (b *ProgressBar) logLine(units SizeUnits) string {
+	e := b.event
+	done := formatSize(e.BytesDone, units)
+	if e.TotalBytes > 0 {
+		done = fmt.Sprintf("%.1f%% (%s / %s)", e.Percent(), done, formatSize(e.TotalBytes, units))
+	}
+	return fmt.Sprintf("%s: %s at %s, %s", b.label, done, formatRate(e.AverageRate, units), phaseStatus(e))
+}
+
+func phaseStatus(e ProgressEvent) string {
+	switch e.Phase {
+	case PHASE_VERIFYING, PHASE_DONE:
+		return string(e.Phase)
+	default:
+		return "ETA " + formatETA(e.ETA)
+	}
+}
+
+func formatSize(bytes int64, units SizeUnits) string {
+	val, unit := HumanizeReadableSize(bytes, units)
+	if val == float64(int64(val)) {
+		return fmt.Sprintf("%.0f %s", val, unit)
+	}
+	return fmt.Sprintf("%.1f %s", val, unit)
+}
+
+func formatRate(rate float64, units SizeUnits) string {
+	return formatSize(int64(rate), units) + "/s"
+}
+
+func formatETA(eta time.Duration) string {
+	if eta < 0 {
+		return "--:--"
+	}
+	eta = eta.Round(time.Second)
+	h, m, s := int(eta.Hours()), int(eta.Minutes())%60, int(eta.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+func truncateText(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	if width == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func terminalWidth(f *os.File) int {
+	if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv(ENV_COLUMNS)); err == nil && width > 0 {
+		return width
+	}
+	return PROGRESS_BAR_DEFAULT_WIDTH
+}
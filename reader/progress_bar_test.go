@@ -0,0 +1,129 @@
+package reader
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	BAR_LABEL       = "ubuntu.iso"
+	BAR_OTHER_LABEL = "debian.iso"
+	BAR_WIDTH       = 60
+	BAR_TOTAL       = 100 << 20
+)
+
+type ProgressBarTestSuite struct {
+	suite.Suite
+	out      bytes.Buffer
+	clock    time.Time
+	renderer *ProgressRenderer
+}
+
+func TestProgressBarTestSuite(t *testing.T) {
+	suite.Run(t, new(ProgressBarTestSuite))
+}
+
+func (s *ProgressBarTestSuite) SetupTest() {
+	s.out.Reset()
+	s.clock = time.Unix(0, 0)
+	s.renderer = NewProgressRenderer(&s.out)
+	s.renderer.now = func() time.Time { return s.clock }
+}
+
+func (s *ProgressBarTestSuite) useTerminal(width int) {
+	s.renderer.tty = true
+	s.renderer.width = func() int { return width }
+}
+
+func halfDone() ProgressEvent {
+	return ProgressEvent{
+		Phase:       PHASE_DOWNLOADING,
+		BytesDone:   BAR_TOTAL / 2,
+		TotalBytes:  BAR_TOTAL,
+		AverageRate: 2 << 20,
+		ETA:         25 * time.Second,
+	}
+}
+
+func (s *ProgressBarTestSuite) TestShouldNotDetectTerminalForBuffer() {
+	s.False(s.renderer.IsTerminal())
+}
+
+func (s *ProgressBarTestSuite) TestBarLineShouldShowPercentSpeedAndETA() {
+	bar := s.renderer.Bar(BAR_LABEL)
+	bar.event = halfDone()
+
+	line := bar.barLine(BAR_WIDTH, SIZE_UNITS_IEC)
+	s.True(strings.HasPrefix(line, BAR_LABEL+" [====="), line)
+	s.Contains(line, " 50.0%")
+	s.Contains(line, "2 MiB/s")
+	s.Contains(line, "ETA 00:25")
+	s.Equal(BAR_WIDTH, utf8.RuneCountInString(line))
+}
+
+func (s *ProgressBarTestSuite) TestBarLineShouldFitNarrowTerminal() {
+	bar := s.renderer.Bar(strings.Repeat("long-name-", 10))
+	bar.event = halfDone()
+
+	for _, width := range []int{20, 30, 45} {
+		line := bar.barLine(width, SIZE_UNITS_IEC)
+		s.LessOrEqual(utf8.RuneCountInString(line), width, line)
+	}
+}
+
+func (s *ProgressBarTestSuite) TestBarLineShouldOmitBarForUnknownSize() {
+	bar := s.renderer.Bar(BAR_LABEL)
+	bar.event = ProgressEvent{Phase: PHASE_DOWNLOADING, BytesDone: 3 << 20, ETA: ETA_UNKNOWN}
+
+	line := bar.barLine(BAR_WIDTH, SIZE_UNITS_IEC)
+	s.NotContains(line, "[")
+	s.Contains(line, "3 MiB")
+	s.Contains(line, "ETA --:--")
+}
+
+func (s *ProgressBarTestSuite) TestTerminalShouldRedrawStackedBarsInPlace() {
+	s.useTerminal(BAR_WIDTH)
+	first := s.renderer.Bar(BAR_LABEL)
+	second := s.renderer.Bar(BAR_OTHER_LABEL)
+
+	first.Report(halfDone())
+	s.NotContains(s.out.String(), fmt.Sprintf(ANSI_CURSOR_UP, 2))
+	s.Equal(2, strings.Count(s.out.String(), "\n"))
+
+	s.out.Reset()
+	second.Report(halfDone())
+	out := s.out.String()
+	s.True(strings.HasPrefix(out, fmt.Sprintf(ANSI_CURSOR_UP, 2)), out)
+	s.Equal(2, strings.Count(out, ANSI_CLEAR_LINE))
+	s.Contains(out, BAR_LABEL)
+	s.Contains(out, BAR_OTHER_LABEL)
+}
+
+func (s *ProgressBarTestSuite) TestPlainOutputShouldLogPeriodically() {
+	bar := s.renderer.Bar(BAR_LABEL)
+
+	for i := 0; i < 10; i++ {
+		bar.Report(halfDone())
+		s.clock = s.clock.Add(time.Second)
+	}
+	bar.Report(ProgressEvent{Phase: PHASE_DONE, BytesDone: BAR_TOTAL, TotalBytes: BAR_TOTAL})
+
+	lines := strings.Split(strings.TrimSpace(s.out.String()), "\n")
+	s.Len(lines, 3)
+	s.Equal(BAR_LABEL+": 50.0% (50 MiB / 100 MiB) at 2 MiB/s, ETA 00:25", lines[0])
+	s.NotContains(s.out.String(), "\x1b[")
+	s.True(strings.HasSuffix(lines[2], "done"), lines[2])
+}
+
+func (s *ProgressBarTestSuite) TestFormatETA() {
+	s.Equal("--:--", formatETA(ETA_UNKNOWN))
+	s.Equal("00:00", formatETA(0))
+	s.Equal("01:05", formatETA(65*time.Second))
+	s.Equal("2:00:01", formatETA(2*time.Hour+time.Second))
+}
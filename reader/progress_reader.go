@@ -1,21 +1,85 @@
 package reader
 
-import "io"
+import (
+	"io"
+	"sync"
+)
 
 type ProgressReader struct {
 	Reader    io.Reader
 	TotalSize int64
 	ReadSize  int64
 	Notify    func(n int64, totalSize int64)
+	Wire      WireCounter
+
+	Reporter ProgressReporter
+	Throttle ProgressThrottle
+
+	mu       sync.Mutex
+	lastWire int64
+	tracker  progressTracker
 }
 
 func (p *ProgressReader) Read(buf []byte) (int, error) {
 	n, err := p.Reader.Read(buf)
-	if n > 0 {
-		p.ReadSize += int64(n)
-		if p.Notify != nil {
-			p.Notify(p.ReadSize, p.TotalSize)
-		}
+	if p.Wire == nil {
+		p.add(n)
+		return n, err
+	}
+
+	wire := p.Wire.WireBytes()
+	p.add(int(wire - p.lastWire))
+	p.lastWire = wire
+	return n, err
+}
+
+// Segment returns a reader whose reads are accounted against p, so several
+// concurrent segments report a single aggregated progress stream.
+func (p *ProgressReader) Segment(r io.Reader) io.Reader {
+	return &progressSegment{reader: r, progress: p}
+}
+
+func (p *ProgressReader) add(n int) {
+	if n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tracker.begin(p.ReadSize)
+	p.ReadSize += int64(n)
+	if p.Notify != nil {
+		p.Notify(p.ReadSize, p.TotalSize)
 	}
+	p.report(false)
+}
+
+// SetPhase moves the transfer to a new phase and reports it immediately.
+func (p *ProgressReader) SetPhase(phase ProgressPhase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tracker.begin(p.ReadSize)
+	p.tracker.phase = phase
+	p.report(true)
+}
+
+func (p *ProgressReader) report(force bool) {
+	if p.Reporter == nil {
+		return
+	}
+	now := p.tracker.now()
+	if !force && !p.tracker.due(p.Throttle, now, p.ReadSize, p.TotalSize) {
+		return
+	}
+	p.Reporter.Report(p.tracker.event(now, p.ReadSize, p.TotalSize))
+}
+
+type progressSegment struct {
+	reader   io.Reader
+	progress *ProgressReader
+}
+
+func (s *progressSegment) Read(buf []byte) (int, error) {
+	n, err := s.reader.Read(buf)
+	s.progress.add(n)
 	return n, err
 }
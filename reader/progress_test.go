@@ -0,0 +1,137 @@
+package reader
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	PROGRESS_CHUNK = 100
+	PROGRESS_TOTAL = 1000
+	PROGRESS_TICK  = 100 * time.Millisecond
+)
+
+type ProgressTestSuite struct {
+	suite.Suite
+	clock  time.Time
+	events []ProgressEvent
+}
+
+func TestProgressTestSuite(t *testing.T) {
+	suite.Run(t, new(ProgressTestSuite))
+}
+
+func (s *ProgressTestSuite) SetupTest() {
+	s.clock = time.Unix(0, 0)
+	s.events = nil
+}
+
+func (s *ProgressTestSuite) newProgressReader(throttle ProgressThrottle) *ProgressReader {
+	pr := &ProgressReader{
+		TotalSize: PROGRESS_TOTAL,
+		Throttle:  throttle,
+		Reporter: ProgressReporterFunc(func(e ProgressEvent) {
+			s.events = append(s.events, e)
+		}),
+	}
+	pr.tracker.now = func() time.Time { return s.clock }
+	return pr
+}
+
+// advance simulates one read of PROGRESS_CHUNK bytes every tick.
+func (s *ProgressTestSuite) advance(pr *ProgressReader, reads int, tick time.Duration) {
+	for i := 0; i < reads; i++ {
+		pr.add(PROGRESS_CHUNK)
+		s.clock = s.clock.Add(tick)
+	}
+}
+
+func (s *ProgressTestSuite) TestZeroThrottleShouldReportEveryRead() {
+	pr := s.newProgressReader(ProgressThrottle{})
+	s.advance(pr, 10, PROGRESS_TICK)
+	s.Len(s.events, 10)
+}
+
+func (s *ProgressTestSuite) TestIntervalShouldThrottleEvents() {
+	pr := s.newProgressReader(ProgressThrottle{Interval: 4 * PROGRESS_TICK})
+	s.advance(pr, 10, PROGRESS_TICK)
+
+	// first read, every fourth tick after it, and completion
+	s.Len(s.events, 4)
+	s.Equal(int64(PROGRESS_TOTAL), s.events[len(s.events)-1].BytesDone)
+}
+
+func (s *ProgressTestSuite) TestPercentShouldThrottleEvents() {
+	pr := s.newProgressReader(ProgressThrottle{Percent: 25})
+	s.advance(pr, 10, PROGRESS_TICK)
+
+	done := make([]int64, 0, len(s.events))
+	for _, e := range s.events {
+		done = append(done, e.BytesDone)
+	}
+	s.Equal([]int64{100, 400, 700, 1000}, done)
+}
+
+func (s *ProgressTestSuite) TestEventShouldCarryRateAndETA() {
+	pr := s.newProgressReader(ProgressThrottle{})
+	s.advance(pr, 5, time.Second)
+
+	last := s.events[len(s.events)-1]
+	s.Equal(PHASE_DOWNLOADING, last.Phase)
+	s.Equal(int64(500), last.BytesDone)
+	s.Equal(float64(PROGRESS_CHUNK), last.Rate)
+	s.InDelta(float64(PROGRESS_CHUNK), last.AverageRate, 0.001)
+	s.Equal(5*time.Second, last.ETA)
+	s.Equal(4*time.Second, last.Elapsed)
+	s.Equal(50.0, last.Percent())
+}
+
+func (s *ProgressTestSuite) TestETAShouldBeUnknownWithoutTotal() {
+	pr := s.newProgressReader(ProgressThrottle{})
+	pr.TotalSize = 0
+	s.advance(pr, 3, time.Second)
+
+	s.Equal(ETA_UNKNOWN, s.events[len(s.events)-1].ETA)
+}
+
+func (s *ProgressTestSuite) TestSetPhaseShouldBypassThrottle() {
+	pr := s.newProgressReader(ProgressThrottle{Interval: time.Hour})
+	s.advance(pr, 3, PROGRESS_TICK)
+	pr.SetPhase(PHASE_VERIFYING)
+
+	s.Len(s.events, 2)
+	s.Equal(PHASE_VERIFYING, s.events[1].Phase)
+}
+
+func (s *ProgressTestSuite) TestStreamToFileShouldReportPhases() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	var phases []ProgressPhase
+	reporter := ProgressReporterFunc(func(e ProgressEvent) {
+		if len(phases) == 0 || phases[len(phases)-1] != e.Phase {
+			phases = append(phases, e.Phase)
+		}
+		s.Equal(int64(FILE_LOCAL_SIZE), e.TotalBytes)
+	})
+
+	sum := sha256.Sum256([]byte(FILE_LOCAL_CONTENT))
+	_, _, err = r.StreamToFile(s.T().TempDir(), WithProgressReporter(reporter), WithChecksum(CHECKSUM_SHA256, hexDigest(sum[:])))
+	s.NoError(err)
+	s.Equal([]ProgressPhase{PHASE_DOWNLOADING, PHASE_VERIFYING, PHASE_DONE}, phases)
+}
+
+func (s *ProgressTestSuite) TestStreamToFileShouldBeSilentWithoutReporter() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	out := captureStderr(func() {
+		_, _, err = r.StreamToFile(s.T().TempDir())
+	})
+	s.NoError(err)
+	s.False(strings.Contains(out, "Downloaded"))
+}
@@ -0,0 +1,223 @@
+package reader
+
+import (
+	"context"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "abc/errors"
+)
+
+const (
+	RATE_LIMIT_MIN_BURST = 16 << 10
+	RATE_SUFFIX          = "/s"
+	RATE_SUFFIX_BPS      = "bps"
+	RATE_UNLIMITED       = "unlimited"
+)
+
+var rateUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+}
+
+var globalRateLimiter = NewRateLimiter(0)
+
+// GlobalRateLimiter is shared by every StreamToFile in the process and is
+// unlimited until SetGlobalRateLimit is called.
+func GlobalRateLimiter() *RateLimiter {
+	return globalRateLimiter
+}
+
+func SetGlobalRateLimit(bytesPerSecond int64) {
+	globalRateLimiter.SetLimit(bytesPerSecond)
+}
+
+// RateLimiter is a token bucket holding up to one second worth of bytes. A
+// limit of zero or less disables it. The limit can be changed while readers
+// are waiting on it.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	changed chan struct{}
+	now     func() time.Time
+}
+
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	l := &RateLimiter{changed: make(chan struct{}), now: time.Now}
+	l.SetLimit(bytesPerSecond)
+	return l
+}
+
+func (l *RateLimiter) Limit() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(l.rate)
+}
+
+func (l *RateLimiter) SetLimit(bytesPerSecond int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	l.rate = math.Max(float64(bytesPerSecond), 0)
+	l.burst = math.Max(l.rate, RATE_LIMIT_MIN_BURST)
+	l.tokens = math.Min(l.tokens, l.burst)
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// chunk is the largest read worth doing at once without overshooting the
+// limit by more than a burst.
+func (l *RateLimiter) chunk() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return math.MaxInt
+	}
+	return int(l.burst)
+}
+
+// WaitN takes n bytes from the bucket, sleeping until the debt is repaid.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	l.refill()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	l.tokens -= float64(n)
+
+	for l.tokens < 0 && l.rate > 0 {
+		delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+		changed := l.changed
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		l.mu.Lock()
+		l.refill()
+	}
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *RateLimiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() && l.rate > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+}
+
+type rateLimitedReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	limiters []*RateLimiter
+}
+
+// NewRateLimitedReader throttles r by every limiter in turn, so a
+// per-download limit composes with a shared global one.
+func NewRateLimitedReader(ctx context.Context, r io.Reader, limiters ...*RateLimiter) io.Reader {
+	return &rateLimitedReader{ctx: orBackground(ctx), reader: r, limiters: limiters}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	for _, l := range r.limiters {
+		if chunk := l.chunk(); len(p) > chunk {
+			p = p[:chunk]
+		}
+	}
+
+	n, err := r.reader.Read(p)
+	for _, l := range r.limiters {
+		if waitErr := l.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+type rateLimitedSegments struct {
+	SegmentedSourceReader
+	ctx      context.Context
+	limiters []*RateLimiter
+}
+
+func (s *rateLimitedSegments) OpenRange(start, end int64) (io.ReadCloser, error) {
+	body, err := s.SegmentedSourceReader.OpenRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{NewRateLimitedReader(s.ctx, body, s.limiters...), body}, nil
+}
+
+// ParseRate parses a bandwidth such as "10MB/s", "512k" or "1.5 MiB/s" into
+// bytes per second. Every unit is binary, as in curl and HumanizeReadableSize,
+// so KB, KiB and K all mean 1024 bytes. A lowercase b after a prefix, as in
+// "10Mb/s", means bits, and bit rates are rejected rather than misread as
+// bytes, as is a "bps" suffix. "0" and "unlimited" disable limiting.
+func ParseRate(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	if strings.EqualFold(s, RATE_UNLIMITED) {
+		return 0, nil
+	}
+	if strings.HasSuffix(strings.ToLower(s), RATE_SUFFIX) {
+		s = s[:len(s)-len(RATE_SUFFIX)]
+	}
+
+	i := strings.IndexFunc(s, func(c rune) bool {
+		return (c < '0' || c > '9') && c != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	number, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || number < 0 {
+		return 0, apperrors.ErrInvalidRate
+	}
+	suffix := strings.TrimSpace(s[i:])
+	if isBitRateUnit(suffix) {
+		return 0, apperrors.ErrInvalidRate
+	}
+	unit, ok := rateUnits[strings.ToLower(suffix)]
+	if !ok {
+		return 0, apperrors.ErrInvalidRate
+	}
+	return int64(number * unit), nil
+}
+
+// isBitRateUnit reports whether suffix names bits rather than bytes: a
+// lowercase b after a prefix, or a bps suffix in any case.
+func isBitRateUnit(suffix string) bool {
+	return strings.HasSuffix(strings.ToLower(suffix), RATE_SUFFIX_BPS) ||
+		(len(suffix) > 1 && strings.HasSuffix(suffix, "b"))
+}
@@ -0,0 +1,144 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "abc/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	RATE_PAYLOAD_SIZE = 64 << 10
+	RATE_LIMIT        = 256 << 10
+	RATE_MIN_ELAPSED  = 200 * time.Millisecond
+	RATE_MAX_ELAPSED  = 2 * time.Second
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	payload []byte
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
+
+func (s *RateLimitTestSuite) SetupTest() {
+	s.payload = bytes.Repeat([]byte("x"), RATE_PAYLOAD_SIZE)
+}
+
+func (s *RateLimitTestSuite) timedRead(r io.Reader) (time.Duration, error) {
+	started := time.Now()
+	data, err := io.ReadAll(r)
+	s.Len(data, RATE_PAYLOAD_SIZE)
+	return time.Since(started), err
+}
+
+func (s *RateLimitTestSuite) TestParseRate() {
+	tests := map[string]int64{
+		"10MB/s":     10 << 20,
+		"10MiB/s":    10 << 20,
+		"1.5 MiB/s":  3 << 19,
+		"512k":       512 << 10,
+		"100KB":      100 << 10,
+		"2G":         2 << 30,
+		"64kB/s":     64 << 10,
+		"2M/S":       2 << 20,
+		"512 B":      512,
+		"4096":       4096,
+		"0":          0,
+		"unlimited":  0,
+		"Unlimited":  0,
+		" 1 GiB/s  ": 1 << 30,
+	}
+	for value, want := range tests {
+		got, err := ParseRate(value)
+		s.NoError(err, value)
+		s.Equal(want, got, value)
+	}
+
+	for _, value := range []string{"", "fast", "10XB/s", "-1MB", "MB/s", "10Mbps", "10mbps", "8kbps", "1Gbit/s", "10Mb/s", "100kb/s", "1gb", "5 MBps"} {
+		_, err := ParseRate(value)
+		s.EqualError(err, apperrors.ERR_INVALID_RATE, value)
+	}
+}
+
+func (s *RateLimitTestSuite) TestUnlimitedShouldNotDelay() {
+	elapsed, err := s.timedRead(NewRateLimitedReader(context.Background(), bytes.NewReader(s.payload), NewRateLimiter(0)))
+	s.NoError(err)
+	s.Less(elapsed, RATE_MIN_ELAPSED)
+}
+
+func (s *RateLimitTestSuite) TestShouldThrottleToLimit() {
+	elapsed, err := s.timedRead(NewRateLimitedReader(context.Background(), bytes.NewReader(s.payload), NewRateLimiter(RATE_LIMIT)))
+	s.NoError(err)
+	s.GreaterOrEqual(elapsed, RATE_MIN_ELAPSED)
+	s.Less(elapsed, RATE_MAX_ELAPSED)
+}
+
+func (s *RateLimitTestSuite) TestSharedLimiterShouldSplitBandwidth() {
+	limiter := NewRateLimiter(2 * RATE_LIMIT)
+	errs := make(chan error, 2)
+	started := time.Now()
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := io.Copy(io.Discard, NewRateLimitedReader(context.Background(), bytes.NewReader(s.payload), limiter))
+			errs <- err
+		}()
+	}
+	s.NoError(<-errs)
+	s.NoError(<-errs)
+	s.GreaterOrEqual(time.Since(started), RATE_MIN_ELAPSED)
+}
+
+func (s *RateLimitTestSuite) TestSetLimitShouldApplyToWaitingReaders() {
+	limiter := NewRateLimiter(1 << 10)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		limiter.SetLimit(0)
+	}()
+
+	elapsed, err := s.timedRead(NewRateLimitedReader(context.Background(), bytes.NewReader(s.payload), limiter))
+	s.NoError(err)
+	s.Less(elapsed, RATE_MAX_ELAPSED)
+	s.Equal(int64(0), limiter.Limit())
+}
+
+func (s *RateLimitTestSuite) TestWaitShouldHonourContext() {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := io.ReadAll(NewRateLimitedReader(ctx, bytes.NewReader(s.payload), NewRateLimiter(1<<10)))
+	s.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func (s *RateLimitTestSuite) TestStreamToFileShouldApplyPerDownloadAndGlobalLimits() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(s.payload)
+	}))
+	defer server.Close()
+
+	for _, opts := range [][]StreamOption{{WithRateLimit(RATE_LIMIT)}, nil} {
+		if opts == nil {
+			SetGlobalRateLimit(RATE_LIMIT)
+		}
+
+		r, err := NewReader(server.URL + "/payload.bin")
+		s.NoError(err)
+
+		started := time.Now()
+		_, n, err := r.StreamToFile(s.T().TempDir(), opts...)
+		SetGlobalRateLimit(0)
+
+		s.NoError(err)
+		s.Equal(int64(RATE_PAYLOAD_SIZE), n)
+		s.GreaterOrEqual(time.Since(started), RATE_MIN_ELAPSED)
+	}
+}
@@ -1,8 +1,12 @@
 package reader
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -23,30 +27,38 @@ const (
 	SCHEME_HTTPS_PREFIX = SCHEME_HTTPS + SCHEME_SUFFIX
 	SCHEME_FILE_PREFIX  = SCHEME_FILE + SCHEME_SUFFIX
 
-	PART_FILE_SUFFIX = ".part"
+	PART_FILE_SUFFIX    = ".part"
+	CORRUPT_FILE_SUFFIX = ".corrupt"
 )
 
-func NewReader(source string) (*Reader, error) {
-	if strings.HasPrefix(source, SCHEME_FILE_PREFIX) {
-		path := strings.TrimPrefix(source, SCHEME_FILE_PREFIX)
-		fileReader, err := NewFileReader(path)
-		if err != nil {
-			return nil, err
-		}
+func NewReader(source string, opts ...Option) (*Reader, error) {
+	return NewReaderContext(context.Background(), source, opts...)
+}
 
-		return &Reader{fileReader}, nil
+func NewReaderContext(ctx context.Context, source string, opts ...Option) (*Reader, error) {
+	options := newOptions(opts)
+	options.Context = ctx
+
+	raw := source
+	if source == STDIN_SOURCE {
+		raw = SCHEME_STDIN_PREFIX
+	}
+	u, err := parseSource(raw)
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.HasPrefix(source, SCHEME_HTTP_PREFIX) || strings.HasPrefix(source, SCHEME_HTTPS_PREFIX) {
-		httpReader, err := NewHTTPReader(source)
-		if err != nil {
-			return nil, err
-		}
+	factory, ok := lookupScheme(u.Scheme)
+	if !ok {
+		return nil, &apperrors.UnsupportedSchemeError{Scheme: u.Scheme}
+	}
 
-		return &Reader{httpReader}, nil
+	src, err := factory(u, options)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New(apperrors.ERR_UNSUPPORTED_SCHEME)
+	return &Reader{src: src, source: source, ctx: ctx, decompression: options.Decompression}, nil
 }
 
 type SourceReader interface {
@@ -55,51 +67,461 @@ type SourceReader interface {
 	TotalSize() int64
 }
 
+// ResumableSourceReader is implemented by sources that can continue a
+// transfer from a byte offset. Validator identifies the version of the
+// content so a part file is never completed with bytes of a different one.
+type ResumableSourceReader interface {
+	SourceReader
+	Validator() string
+	ResumeFrom(offset int64) (int64, error)
+}
+
+// HeaderSourceReader is implemented by sources that obtained response
+// headers while resolving the source.
+type HeaderSourceReader interface {
+	SourceReader
+	Header() http.Header
+}
+
 type Reader struct {
-	src SourceReader
+	src    SourceReader
+	source string
+	ctx    context.Context
+
+	decompression DecompressionMode
+	format        CompressionFormat
+	detected      bool
+	stream        io.Reader
 }
 
 func (r *Reader) Read(p []byte) (int, error) {
 	if r.src == nil {
 		return 0, io.EOF
 	}
-	return r.src.Read(p)
+	if r.stream == nil {
+		stream, err := r.decompressStream(r.src)
+		if err != nil {
+			return 0, err
+		}
+		r.stream = stream
+	}
+	return r.stream.Read(p)
+}
+
+func (r *Reader) Filename() string {
+	if r.src == nil {
+		return ""
+	}
+
+	name := r.src.Filename()
+	if r.decompression == DECOMPRESS_OFF {
+		return name
+	}
+	if r.detected {
+		return StripCompressionExtension(name, r.format)
+	}
+	return StripCompressionExtension(name, detectCompressionByExtension(name))
+}
+
+// Close releases the underlying source, such as an open file or response
+// body, and any decompressor reading from it.
+func (r *Reader) Close() error {
+	if closer, ok := r.stream.(io.Closer); ok {
+		closer.Close()
+	}
+	if closer, ok := r.src.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (r *Reader) Source() string {
+	return r.source
+}
+
+// TotalSize returns the size of the source as transferred, before any
+// decompression, or a non-positive value when unknown.
+func (r *Reader) TotalSize() int64 {
+	if r.src == nil {
+		return 0
+	}
+	return r.src.TotalSize()
+}
+
+func (r *Reader) Header() http.Header {
+	if hs, ok := r.src.(HeaderSourceReader); ok {
+		return hs.Header()
+	}
+	return nil
+}
+
+func (r *Reader) decompressStream(src io.Reader) (io.Reader, error) {
+	stream, format, err := decompress(src, r.src.Filename(), r.decompression)
+	if err != nil {
+		return nil, err
+	}
+	r.format = format
+	r.detected = r.decompression != DECOMPRESS_OFF
+	return stream, nil
+}
+
+// StreamResult describes a finished StreamToFile. Path is the part file when
+// the transfer failed and was kept, or the existing file when it was skipped.
+// FilenameAltered reports that the name offered by the source was unsafe and
+// had to be sanitized. Action tells how the destination name was resolved.
+type StreamResult struct {
+	Path             string
+	Size             int64
+	OriginalFilename string
+	FilenameAltered  bool
+	Action           FinalizeAction
+	Verified         []ChecksumAlgorithm
+}
+
+func (r *Reader) StreamToFile(destinationFolder string, opts ...StreamOption) (string, int64, error) {
+	return r.StreamToFileContext(context.Background(), destinationFolder, opts...)
+}
+
+func (r *Reader) StreamToFileContext(ctx context.Context, destinationFolder string, opts ...StreamOption) (string, int64, error) {
+	result, err := r.SaveContext(ctx, destinationFolder, opts...)
+	return result.Path, result.Size, err
+}
+
+// StreamTo writes the content of the source to w with the same progress
+// reporting, rate limiting and checksum verification as StreamToFile. Bytes
+// reach w as they arrive, so a checksum mismatch is reported only after w
+// has received everything. Options about the destination file are ignored.
+func (r *Reader) StreamTo(w io.Writer, opts ...StreamOption) (int64, error) {
+	return r.StreamToContext(context.Background(), w, opts...)
 }
 
-func (r *Reader) StreamToFile(destinationFolder string) (string, int64, error) {
+func (r *Reader) StreamToContext(ctx context.Context, w io.Writer, opts ...StreamOption) (int64, error) {
 	if r.src == nil {
-		return "", 0, errors.New(apperrors.ERR_READER_SOURCE_NIL)
+		return 0, apperrors.ErrReaderSourceNil
+	}
+
+	options := newStreamOptions(opts)
+	checksums := r.checksums(options)
+	verifier, err := newChecksumVerifier(checksums)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, release := r.bindContext(ctx)
+	defer release()
+
+	pr := r.progressReader(ctx, options, 0)
+	n, err := r.copyDecompressed(w, pr, verifier)
+	if err != nil {
+		return n, err
+	}
+
+	if len(checksums) > 0 {
+		pr.SetPhase(PHASE_VERIFYING)
 	}
+	if err := verifier.verify(""); err != nil {
+		return n, err
+	}
+	pr.SetPhase(PHASE_DONE)
+	return n, nil
+}
+
+// saveName is the unsanitized name a download is saved under: the
+// WithFilename override when set, otherwise the source's own name.
+func (r *Reader) saveName(options StreamOptions) string {
+	if options.Filename != "" {
+		return options.Filename
+	}
+	return r.Filename()
+}
+
+func (r *Reader) Save(destinationFolder string, opts ...StreamOption) (StreamResult, error) {
+	return r.SaveContext(context.Background(), destinationFolder, opts...)
+}
+
+func (r *Reader) SaveContext(ctx context.Context, destinationFolder string, opts ...StreamOption) (StreamResult, error) {
+	if r.src == nil {
+		return StreamResult{}, apperrors.ErrReaderSourceNil
+	}
+
+	options := newStreamOptions(opts)
+	checksums := r.checksums(options)
+	verifier, err := newChecksumVerifier(checksums)
+	if err != nil {
+		return StreamResult{}, err
+	}
+
+	originalName := r.saveName(options)
+	finalName, altered := SanitizeFilename(originalName)
+	finalPath := filepath.Join(destinationFolder, finalName)
+	if !isWithinFolder(destinationFolder, finalPath) {
+		return StreamResult{}, apperrors.ErrUnsafeFilename
+	}
+
+	if existing, skip, err := r.precheckCollision(finalPath, options.CollisionPolicy, checksums); err != nil || skip {
+		existing.OriginalFilename, existing.FilenameAltered = originalName, altered
+		return existing, err
+	}
+
+	ctx, release := r.bindContext(ctx)
+	defer release()
 
-	tempPath := filepath.Join(destinationFolder, uuid.New().String()+PART_FILE_SUFFIX)
-	out, err := os.Create(tempPath)
+	segmented, isSegmented := r.segmentedSource()
+	out, tempPath, offset, err := r.openPartFile(destinationFolder, !isSegmented)
 	if err != nil {
-		return "", 0, err
+		return StreamResult{}, err
 	}
 	defer out.Close()
 
+	if isSegmented {
+		segmented = &rateLimitedSegments{SegmentedSourceReader: segmented, ctx: ctx, limiters: r.limiters(options)}
+	}
+	pr := r.progressReader(ctx, options, offset)
+
+	var n int64
+	if isSegmented {
+		n, err = downloadSegments(destinationFile{out}, segmented, pr)
+		if err == nil {
+			_, err = io.Copy(verifier, io.NewSectionReader(out, 0, n))
+		}
+	} else {
+		if offset > 0 {
+			_, err = io.Copy(verifier, io.NewSectionReader(out, 0, offset))
+		}
+		if err == nil {
+			n, err = r.copyDecompressed(destinationFile{out}, pr, verifier)
+		}
+		n += offset
+	}
+	if err != nil && err != io.EOF {
+		return r.abandonPartFile(ctx, out, tempPath, n, err, options.PartFilePolicy)
+	}
+
+	if len(checksums) > 0 {
+		pr.SetPhase(PHASE_VERIFYING)
+	}
+	if err := verifier.verify(tempPath); err != nil {
+		return r.abandonCorruptFile(ctx, out, tempPath, n, err, options.PartFilePolicy)
+	}
+
+	result := StreamResult{Path: tempPath, Size: n, OriginalFilename: originalName, FilenameAltered: altered}
+	for _, c := range checksums {
+		result.Verified = append(result.Verified, c.Algorithm)
+	}
+	if err := out.Sync(); err != nil {
+		return result, destinationError(err)
+	}
+	if err := out.Close(); err != nil {
+		return result, destinationError(err)
+	}
+
+	finalPath, result.Action, err = finalizeFile(tempPath, finalPath, options.CollisionPolicy)
+	if finalPath != "" {
+		result.Path = finalPath
+	}
+	if result.Action == ACTION_SKIPPED {
+		if info, statErr := os.Stat(finalPath); statErr == nil {
+			result.Size = info.Size()
+		}
+	}
+	if err == nil {
+		pr.SetPhase(PHASE_DONE)
+	}
+	return result, err
+}
+
+func (r *Reader) checksums(options StreamOptions) []Checksum {
+	if cs, ok := r.src.(ChecksumSourceReader); ok && len(options.Checksums) == 0 {
+		return cs.Checksums()
+	}
+	return options.Checksums
+}
+
+// bindContext hands the merged context to the source for the duration of a
+// transfer. The returned func restores the source and releases the context.
+func (r *Reader) bindContext(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := mergeContext(ctx, r.ctx)
+	cs, ok := r.src.(ContextualSourceReader)
+	if !ok {
+		return ctx, cancel
+	}
+	cs.SetContext(ctx)
+	return ctx, func() {
+		cs.SetContext(orBackground(r.ctx))
+		cancel()
+	}
+}
+
+func (r *Reader) limiters(options StreamOptions) []*RateLimiter {
+	return append(options.RateLimiters, globalRateLimiter)
+}
+
+func (r *Reader) progressReader(ctx context.Context, options StreamOptions, offset int64) *ProgressReader {
 	pr := &ProgressReader{
-		Reader:    r.src,
+		Reader:    NewRateLimitedReader(ctx, &contextReader{ctx: ctx, reader: r.src}, r.limiters(options)...),
 		TotalSize: r.src.TotalSize(),
-		Notify:    NotifyProgress,
+		ReadSize:  offset,
+		Reporter:  options.Progress,
+		Throttle:  options.ProgressThrottle,
+	}
+	if wc, ok := r.src.(WireCounter); ok {
+		pr.Wire = wc
 	}
+	return pr
+}
 
-	n, err := io.Copy(out, pr)
-	if err != nil && err != io.EOF {
-		return tempPath, n, err
+// copyDecompressed writes the decompressed content of src to dst and feeds
+// the raw bytes to verifier, since checksums cover the source bytes before
+// any decompression.
+func (r *Reader) copyDecompressed(dst io.Writer, src io.Reader, verifier io.Writer) (int64, error) {
+	source := io.TeeReader(src, verifier)
+	body, err := r.decompressStream(source)
+	if err != nil {
+		return 0, err
 	}
+	if closer, ok := body.(io.Closer); ok {
+		defer closer.Close()
+	}
+	n, err := io.Copy(dst, body)
+	if err == nil && r.format != COMPRESSION_NONE {
+		_, err = io.Copy(io.Discard, source)
+	}
+	return n, err
+}
 
-	finalName := r.src.Filename()
-	finalPath := filepath.Join(destinationFolder, finalName)
+func (r *Reader) abandonPartFile(ctx context.Context, out *os.File, tempPath string, n int64, err error, policy PartFilePolicy) (StreamResult, error) {
+	result := StreamResult{Path: tempPath, Size: n, OriginalFilename: r.Filename()}
+	remove := policy == PART_FILE_REMOVE_ON_ERROR ||
+		(policy == PART_FILE_REMOVE_ON_CANCEL && ctx.Err() != nil)
+	if !remove {
+		return result, err
+	}
+
+	out.Close()
+	if removeErr := os.Remove(tempPath); removeErr != nil && !os.IsNotExist(removeErr) {
+		return result, errors.Join(err, removeErr)
+	}
+	result.Path = ""
+	return result, err
+}
+
+// abandonCorruptFile moves a part file that failed verification out of the
+// way, so its bytes are never resumed. Failing that, it is emptied. The
+// mismatch error points at wherever the file ends up.
+func (r *Reader) abandonCorruptFile(ctx context.Context, out *os.File, tempPath string, n int64, err error, policy PartFilePolicy) (StreamResult, error) {
+	out.Close()
+	path := strings.TrimSuffix(tempPath, PART_FILE_SUFFIX) + CORRUPT_FILE_SUFFIX
+	if renameErr := os.Rename(tempPath, path); renameErr != nil {
+		path = tempPath
+		err = errors.Join(err, renameErr, os.Truncate(tempPath, 0))
+	}
+
+	result, err := r.abandonPartFile(ctx, out, path, n, err, policy)
+	var mismatch *ChecksumMismatchError
+	if errors.As(err, &mismatch) {
+		mismatch.Path = result.Path
+	}
+	return result, err
+}
+
+func (r *Reader) segmentedSource() (SegmentedSourceReader, bool) {
+	segmented, ok := r.src.(SegmentedSourceReader)
+	if !ok || segmented.SegmentCount() <= 1 || r.decompression != DECOMPRESS_OFF {
+		return nil, false
+	}
+	return segmented, true
+}
+
+func (r *Reader) partFilePath(destinationFolder string) string {
+	rs, ok := r.src.(ResumableSourceReader)
+	if !ok || r.source == "" {
+		return filepath.Join(destinationFolder, uuid.New().String()+PART_FILE_SUFFIX)
+	}
+
+	sum := sha256.Sum256([]byte(r.source + "\x00" + rs.Validator()))
+	return filepath.Join(destinationFolder, hex.EncodeToString(sum[:16])+PART_FILE_SUFFIX)
+}
 
-	finalPath, err = GetUniqueFilePath(finalPath)
+// openPartFile opens and locks the part file for this source, resuming it
+// when resume is set and the source allows it. A part file locked by another
+// transfer is left alone and a uniquely named one is used instead, so two
+// transfers never write into the same file.
+func (r *Reader) openPartFile(destinationFolder string, resume bool) (*os.File, string, int64, error) {
+	tempPath := r.partFilePath(destinationFolder)
+	out, err := os.OpenFile(tempPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, "", 0, destinationError(err)
+	}
+	locked, err := lockFile(out)
 	if err != nil {
-		return tempPath, n, err
+		out.Close()
+		return nil, "", 0, destinationError(err)
+	}
+	if !locked {
+		out.Close()
+		tempPath = filepath.Join(destinationFolder, uuid.New().String()+PART_FILE_SUFFIX)
+		out, err = os.OpenFile(tempPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, "", 0, destinationError(err)
+		}
+		resume = false
+	}
+
+	offset, err := r.resumePartFile(out, resume)
+	if err != nil {
+		out.Close()
+		return nil, "", 0, err
+	}
+	return out, tempPath, offset, nil
+}
+
+func (r *Reader) resumePartFile(out *os.File, resume bool) (int64, error) {
+	var offset int64
+	rs, ok := r.src.(ResumableSourceReader)
+	if resume && ok && r.decompression == DECOMPRESS_OFF {
+		info, err := out.Stat()
+		if err != nil {
+			return 0, err
+		}
+		if info.Size() > 0 {
+			offset, err = rs.ResumeFrom(info.Size())
+			if err != nil {
+				return 0, err
+			}
+		}
 	}
 
-	if renameErr := os.Rename(tempPath, finalPath); renameErr != nil {
-		return tempPath, n, renameErr
+	if err := out.Truncate(offset); err != nil {
+		return 0, destinationError(err)
 	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return 0, destinationError(err)
+	}
+	return offset, nil
+}
+
+// destinationError tags a failure writing the output so callers can tell it
+// from a failure reading the source.
+func destinationError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &apperrors.DestinationError{Err: err}
+}
+
+// destinationFile tags write failures on the part file, such as a full disk,
+// as destination errors. It does not embed the file, so io.Copy cannot go
+// around Write through the file's ReadFrom.
+type destinationFile struct {
+	file *os.File
+}
+
+func (f destinationFile) Write(p []byte) (int, error) {
+	n, err := f.file.Write(p)
+	return n, destinationError(err)
+}
 
-	return finalPath, n, nil
+func (f destinationFile) WriteAt(p []byte, off int64) (int, error) {
+	n, err := f.file.WriteAt(p, off)
+	return n, destinationError(err)
 }
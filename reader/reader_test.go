@@ -1,12 +1,20 @@
 package reader
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	apperrors "abc/errors"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -27,13 +35,24 @@ const (
 	HTTP_OK_FILE_CONTENT     = "This is a simple text file for testing purposes."
 	HTTP_NOT_EXIST_FILE_PATH = "/" + "does-not-exist"
 
+	// Resume constants
+	HTTP_RESUME_FILE_NAME     = "resume.bin"
+	HTTP_RESUME_FILE_PATH     = "/" + HTTP_RESUME_FILE_NAME
+	HTTP_NO_RANGE_FILE_NAME   = "no-range.bin"
+	HTTP_NO_RANGE_FILE_PATH   = "/" + HTTP_NO_RANGE_FILE_NAME
+	HTTP_RESUME_ETAG          = `"resume-v1"`
+	HTTP_RESUME_PARTIAL_BYTES = 10
+
 	// Unsupported scheme
 	UNSUPPORTED_SCHEME_URL = "ftp://test.txt"
 )
 
+var HTTP_RESUME_CONTENT = strings.Repeat("0123456789abcdef", 64)
+
 type ReaderTestSuite struct {
 	suite.Suite
-	server *httptest.Server
+	server      *httptest.Server
+	rangeHeader string
 }
 
 func TestReaderTestSuite(t *testing.T) {
@@ -42,11 +61,27 @@ func TestReaderTestSuite(t *testing.T) {
 }
 
 func (s *ReaderTestSuite) SetupTest() {
+	s.rangeHeader = ""
 	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case HTTP_OK_FILE_PATH:
 			w.WriteHeader(http.StatusOK)
 			io.WriteString(w, HTTP_OK_FILE_CONTENT)
+		case HTTP_RESUME_FILE_PATH:
+			if r.Method == http.MethodGet {
+				s.rangeHeader = r.Header.Get("Range")
+			}
+			w.Header().Set("ETag", HTTP_RESUME_ETAG)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, HTTP_RESUME_FILE_NAME, time.Time{}, strings.NewReader(HTTP_RESUME_CONTENT))
+		case HTTP_NO_RANGE_FILE_PATH:
+			if r.Method == http.MethodGet {
+				s.rangeHeader = r.Header.Get("Range")
+			}
+			w.Header().Set("ETag", HTTP_RESUME_ETAG)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, HTTP_RESUME_CONTENT)
 		default:
 			http.NotFound(w, r)
 		}
@@ -69,7 +104,11 @@ func (s *ReaderTestSuite) TestNewReaderShouldReturnErrorIfFileDoesNotExist() {
 
 	s.Error(err)
 	s.Nil(r)
-	s.Equal("file not found", err.Error())
+	s.ErrorIs(err, apperrors.ErrFileNotFound)
+	s.ErrorIs(err, fs.ErrNotExist)
+	var nf *apperrors.SourceNotFoundError
+	s.ErrorAs(err, &nf)
+	s.Equal("not-ok.txt", nf.Path)
 }
 
 func (s *ReaderTestSuite) TestNewReaderShouldSuccessForHTTP() {
@@ -86,14 +125,21 @@ func (s *ReaderTestSuite) TestNewReaderShouldReturnErrorIfHTTPDoesNotExist() {
 
 	s.Error(err)
 	s.Nil(r)
-	s.Equal("url not exists", err.Error())
+	s.ErrorIs(err, apperrors.ErrURLNotExists)
+	var se *apperrors.HTTPStatusError
+	s.ErrorAs(err, &se)
+	s.Equal(http.StatusNotFound, se.Code)
+	s.Equal(url, se.URL)
 }
 func (s *ReaderTestSuite) TestNewReaderShouldReturnErrorIfUnsupportedScheme() {
 	r, err := NewReader(UNSUPPORTED_SCHEME_URL)
 
 	s.Error(err)
 	s.Nil(r)
-	s.Equal("unsupported scheme", err.Error())
+	s.ErrorIs(err, apperrors.ErrUnsupportedScheme)
+	var use *apperrors.UnsupportedSchemeError
+	s.ErrorAs(err, &use)
+	s.Equal("ftp", use.Scheme)
 }
 
 func (s *ReaderTestSuite) TestReadShouldReturnEOFIfReaderIsNil() {
@@ -125,6 +171,49 @@ func (s *ReaderTestSuite) TestStreamToFileShouldReturnErrorIfReaderIsNil() {
 	s.Equal("reader source is nil", err.Error())
 }
 
+func (s *ReaderTestSuite) TestStreamToShouldReturnErrorIfReaderIsNil() {
+	r := &Reader{}
+
+	n, err := r.StreamTo(io.Discard)
+	s.ErrorIs(err, apperrors.ErrReaderSourceNil)
+	s.Equal(int64(0), n)
+}
+
+func (s *ReaderTestSuite) TestStreamToShouldWriteContentToWriter() {
+	r, err := NewReader(s.server.URL+HTTP_RESUME_FILE_PATH, WithSegments(4))
+	s.NoError(err)
+
+	var buf strings.Builder
+	var events []ProgressEvent
+	n, err := r.StreamTo(&buf, WithProgressReporter(ProgressReporterFunc(func(e ProgressEvent) {
+		events = append(events, e)
+	})))
+	s.NoError(err)
+	s.Equal(int64(len(HTTP_RESUME_CONTENT)), n)
+	s.Equal(HTTP_RESUME_CONTENT, buf.String())
+	s.Require().NotEmpty(events)
+	s.Equal(PHASE_DONE, events[len(events)-1].Phase)
+	s.Equal(int64(len(HTTP_RESUME_CONTENT)), events[len(events)-1].BytesDone)
+}
+
+func (s *ReaderTestSuite) TestStreamToShouldVerifyChecksums() {
+	sum := sha256.Sum256([]byte(FILE_LOCAL_CONTENT))
+
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+	var buf strings.Builder
+	_, err = r.StreamTo(&buf, WithChecksum(CHECKSUM_SHA256, hex.EncodeToString(sum[:])))
+	s.NoError(err)
+	s.Equal(FILE_LOCAL_CONTENT, buf.String())
+
+	r, err = NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+	buf.Reset()
+	_, err = r.StreamTo(&buf, WithChecksum(CHECKSUM_SHA256, strings.Repeat("0", 64)))
+	s.ErrorIs(err, apperrors.ErrChecksumMismatch)
+	s.Equal(FILE_LOCAL_CONTENT, buf.String())
+}
+
 func (s *ReaderTestSuite) TestStreamToFileShouldWriteFileForLocalFile() {
 	r, err := NewReader(FILE_LOCAL_SCHEME)
 	s.NoError(err)
@@ -184,3 +273,115 @@ func (s *ReaderTestSuite) TestStreamToFileShouldWriteFileForHTTP() {
 	s.NoError(readErr)
 	s.Equal(expected, string(data))
 }
+
+func (s *ReaderTestSuite) TestStreamToFileShouldUseDeterministicPartFileName() {
+	url := s.server.URL + HTTP_RESUME_FILE_PATH
+	r1, err := NewReader(url)
+	s.NoError(err)
+	r2, err := NewReader(url)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	s.Equal(r1.partFilePath(dest), r2.partFilePath(dest))
+	s.True(strings.HasSuffix(r1.partFilePath(dest), PART_FILE_SUFFIX))
+}
+
+func (s *ReaderTestSuite) TestStreamToFileShouldResumeHTTPFromPartFile() {
+	url := s.server.URL + HTTP_RESUME_FILE_PATH
+	r, err := NewReader(url)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	partPath := r.partFilePath(dest)
+	s.NoError(os.WriteFile(partPath, []byte(HTTP_RESUME_CONTENT[:HTTP_RESUME_PARTIAL_BYTES]), 0o644))
+
+	path, n, err := r.StreamToFile(dest)
+	s.NoError(err)
+	s.Equal(HTTP_RESUME_FILE_NAME, filepath.Base(path))
+	s.Equal(int64(len(HTTP_RESUME_CONTENT)), n)
+	s.Equal("bytes=10-", s.rangeHeader)
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(HTTP_RESUME_CONTENT, string(data))
+
+	_, statErr := os.Stat(partPath)
+	s.True(os.IsNotExist(statErr))
+}
+
+func (s *ReaderTestSuite) TestStreamToFileShouldNotShareLockedPartFile() {
+	r, err := NewReader(s.server.URL + HTTP_RESUME_FILE_PATH)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	partPath := r.partFilePath(dest)
+	s.NoError(os.WriteFile(partPath, []byte(HTTP_RESUME_CONTENT[:HTTP_RESUME_PARTIAL_BYTES]), 0o644))
+	busy, err := os.OpenFile(partPath, os.O_RDWR, 0o644)
+	s.Require().NoError(err)
+	defer busy.Close()
+	locked, err := lockFile(busy)
+	s.Require().NoError(err)
+	s.Require().True(locked)
+
+	path, n, err := r.StreamToFile(dest)
+	s.NoError(err)
+	s.Equal(int64(len(HTTP_RESUME_CONTENT)), n)
+	s.Equal("", s.rangeHeader)
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(HTTP_RESUME_CONTENT, string(data))
+
+	part, readErr := os.ReadFile(partPath)
+	s.NoError(readErr)
+	s.Equal(HTTP_RESUME_CONTENT[:HTTP_RESUME_PARTIAL_BYTES], string(part))
+}
+
+func (s *ReaderTestSuite) TestPartFileWriteFailuresShouldBeDestinationErrors() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.Require().NoError(err)
+
+	_, err = r.copyDecompressed(destinationFile{readOnlyFile(s.T())}, strings.NewReader(FILE_LOCAL_CONTENT), io.Discard)
+	var destErr *apperrors.DestinationError
+	s.True(errors.As(err, &destErr))
+
+	var pathErr *fs.PathError
+	s.True(errors.As(err, &pathErr))
+	s.Equal("write", pathErr.Op)
+}
+
+func (s *ReaderTestSuite) TestStreamToFileShouldRestartIfServerIgnoresRange() {
+	url := s.server.URL + HTTP_NO_RANGE_FILE_PATH
+	r, err := NewReader(url)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	partPath := r.partFilePath(dest)
+	s.NoError(os.WriteFile(partPath, []byte("garbage-bytes"), 0o644))
+
+	path, n, err := r.StreamToFile(dest)
+	s.NoError(err)
+	s.Equal(int64(len(HTTP_RESUME_CONTENT)), n)
+	s.Equal("bytes=13-", s.rangeHeader)
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(HTTP_RESUME_CONTENT, string(data))
+}
+
+func (s *ReaderTestSuite) TestStreamToFileShouldResumeLocalFileFromPartFile() {
+	r, err := NewReader(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	partPath := r.partFilePath(dest)
+	s.NoError(os.WriteFile(partPath, []byte(FILE_LOCAL_CONTENT[:6]), 0o644))
+
+	path, n, err := r.StreamToFile(dest)
+	s.NoError(err)
+	s.Equal(int64(FILE_LOCAL_SIZE), n)
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(FILE_LOCAL_CONTENT, string(data))
+}
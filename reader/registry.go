@@ -0,0 +1,70 @@
+package reader
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type SchemeFactory func(u *url.URL, opts Options) (SourceReader, error)
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[string]SchemeFactory{}
+)
+
+func init() {
+	RegisterScheme(SCHEME_FILE, newFileSource)
+	RegisterScheme(SCHEME_HTTP, newHTTPSource)
+	RegisterScheme(SCHEME_HTTPS, newHTTPSource)
+	RegisterScheme(SCHEME_S3, newS3Source)
+	RegisterScheme(SCHEME_STDIN, newStdinSource)
+}
+
+// RegisterScheme makes a source available to NewReader under the given URL
+// scheme. Registering an existing scheme replaces its factory.
+func RegisterScheme(name string, factory func(u *url.URL, opts Options) (SourceReader, error)) {
+	if name == "" || factory == nil {
+		panic("reader: RegisterScheme requires a scheme name and a factory")
+	}
+
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	schemes[strings.ToLower(name)] = factory
+}
+
+func lookupScheme(name string) (SchemeFactory, bool) {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	factory, ok := schemes[strings.ToLower(name)]
+	return factory, ok
+}
+
+// literalSchemes address paths and object keys, so everything after the
+// scheme is taken as is instead of being parsed as a URL; a path may contain
+// spaces, '#', '?' or '%'. The factory finds it in u.Opaque.
+var literalSchemes = map[string]bool{
+	SCHEME_FILE:  true,
+	SCHEME_STDIN: true,
+	SCHEME_S3:    true,
+}
+
+func parseSource(source string) (*url.URL, error) {
+	scheme, rest, ok := strings.Cut(source, SCHEME_SUFFIX)
+	if ok && literalSchemes[strings.ToLower(scheme)] {
+		return &url.URL{Scheme: strings.ToLower(scheme), Opaque: rest}, nil
+	}
+	return url.Parse(source)
+}
+
+func newFileSource(u *url.URL, opts Options) (SourceReader, error) {
+	path := u.Host + u.Path
+	if u.Opaque != "" {
+		path = u.Opaque
+	}
+	return newFileReader(path, opts)
+}
+
+func newHTTPSource(u *url.URL, opts Options) (SourceReader, error) {
+	return newHTTPReader(u.String(), opts)
+}
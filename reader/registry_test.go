@@ -0,0 +1,134 @@
+package reader
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apperrors "abc/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	REG_SCHEME         = "memtest"
+	REG_SOURCE         = REG_SCHEME + "://bucket/greeting.txt"
+	REG_FILE_NAME      = "greeting.txt"
+	REG_FILE_CONTENT   = "hello from a registered scheme"
+	REG_BAD_URL        = "http://bad host/"
+	REG_UNKNOWN_SCHEME = "gopher://example.com/file"
+)
+
+type memSource struct {
+	*strings.Reader
+	name string
+}
+
+func (m *memSource) Filename() string {
+	return m.name
+}
+
+func (m *memSource) TotalSize() int64 {
+	return m.Size()
+}
+
+type RegistryTestSuite struct {
+	suite.Suite
+	gotURL     *url.URL
+	gotOptions Options
+}
+
+func TestRegistryTestSuite(t *testing.T) {
+	suite.Run(t, new(RegistryTestSuite))
+}
+
+func (s *RegistryTestSuite) SetupTest() {
+	RegisterScheme(REG_SCHEME, func(u *url.URL, opts Options) (SourceReader, error) {
+		s.gotURL = u
+		s.gotOptions = opts
+		return &memSource{Reader: strings.NewReader(REG_FILE_CONTENT), name: filepath.Base(u.Path)}, nil
+	})
+}
+
+func (s *RegistryTestSuite) TestNewReaderShouldDispatchToRegisteredScheme() {
+	ctx := context.Background()
+	r, err := NewReaderContext(ctx, REG_SOURCE, WithSegments(3))
+	s.NoError(err)
+	s.NotNil(r)
+
+	s.Equal("bucket", s.gotURL.Host)
+	s.Equal("/greeting.txt", s.gotURL.Path)
+	s.Equal(3, s.gotOptions.Segments)
+	s.Equal(ctx, s.gotOptions.Context)
+
+	dest := s.T().TempDir()
+	path, n, err := r.StreamToFile(dest)
+	s.NoError(err)
+	s.Equal(REG_FILE_NAME, filepath.Base(path))
+	s.Equal(int64(len(REG_FILE_CONTENT)), n)
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(REG_FILE_CONTENT, string(data))
+}
+
+func (s *RegistryTestSuite) TestFileSchemeShouldTakePathLiterally() {
+	dir := s.T().TempDir()
+	// decoys for what a URL parser would cut the names down to
+	s.NoError(os.WriteFile(filepath.Join(dir, "a"), []byte("decoy"), 0o644))
+	s.NoError(os.WriteFile(filepath.Join(dir, "q"), []byte("decoy"), 0o644))
+
+	for _, name := range []string{"my file.txt", "a#1.txt", "q?x=1.txt", "100%.txt", "%41.txt"} {
+		path := filepath.Join(dir, name)
+		s.NoError(os.WriteFile(path, []byte(name), 0o644))
+
+		r, err := NewReader(SCHEME_FILE_PREFIX + path)
+		s.Require().NoError(err, name)
+		s.Equal(name, r.Filename())
+
+		data, err := io.ReadAll(r)
+		s.NoError(err)
+		s.Equal(name, string(data))
+	}
+}
+
+func (s *RegistryTestSuite) TestNewReaderShouldMatchSchemeCaseInsensitively() {
+	r, err := NewReader(strings.ToUpper(REG_SCHEME) + "://bucket/greeting.txt")
+	s.NoError(err)
+	s.NotNil(r)
+}
+
+func (s *RegistryTestSuite) TestNewReaderShouldReturnErrorForUnknownScheme() {
+	r, err := NewReader(REG_UNKNOWN_SCHEME)
+	s.Nil(r)
+	s.ErrorIs(err, apperrors.ErrUnsupportedScheme)
+}
+
+func (s *RegistryTestSuite) TestNewReaderShouldReturnErrorForUnparsableSource() {
+	r, err := NewReader(REG_BAD_URL)
+	s.Nil(r)
+	s.Error(err)
+}
+
+func (s *RegistryTestSuite) TestNewFileSourceShouldKeepRelativePaths() {
+	u, err := url.Parse(FILE_LOCAL_SCHEME)
+	s.NoError(err)
+
+	src, err := newFileSource(u, newOptions(nil))
+	s.NoError(err)
+	s.Equal(FILE_LOCAL_NAME, src.Filename())
+	s.Equal(int64(FILE_LOCAL_SIZE), src.TotalSize())
+}
+
+func (s *RegistryTestSuite) TestRegisterSchemeShouldPanicWithoutFactory() {
+	s.Panics(func() {
+		RegisterScheme(REG_SCHEME, nil)
+	})
+	s.Panics(func() {
+		RegisterScheme("", newFileSource)
+	})
+}
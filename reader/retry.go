@@ -0,0 +1,153 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	apperrors "abc/errors"
+)
+
+const HEADER_RETRY_AFTER = "Retry-After"
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64
+	// Notify is called before waiting for the next attempt.
+	Notify func(attempt int, delay time.Duration, err error)
+}
+
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !p.retryable(ctx, attempt, err) || !p.wait(ctx, attempt, err) {
+			return err
+		}
+	}
+}
+
+// retryable reports whether attempt failed with err and another one is
+// allowed.
+func (p RetryPolicy) retryable(ctx context.Context, attempt int, err error) bool {
+	return attempt < p.MaxAttempts && ctx.Err() == nil && IsRetryable(err)
+}
+
+// wait notifies and sleeps before the attempt after attempt. It reports false
+// when ctx ended first.
+func (p RetryPolicy) wait(ctx context.Context, attempt int, err error) bool {
+	delay := p.delay(attempt, err)
+	if p.Notify != nil {
+		p.Notify(attempt, delay, err)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// delay is the backoff before the attempt after attempt. A longer Retry-After
+// from the server is honoured up to MaxBackoff.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff < 0 || (p.MaxBackoff > 0 && backoff > p.MaxBackoff) {
+		backoff = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		backoff -= time.Duration(float64(backoff) * p.Jitter * rand.Float64())
+	}
+
+	var se *apperrors.HTTPStatusError
+	if errors.As(err, &se) && se.RetryAfter > backoff {
+		if p.MaxBackoff > 0 && se.RetryAfter > p.MaxBackoff {
+			return p.MaxBackoff
+		}
+		return se.RetryAfter
+	}
+	return backoff
+}
+
+// retryableError is implemented by errors that know whether they are
+// transient, such as apperrors.HTTPStatusError.
+type retryableError interface {
+	error
+	Retryable() bool
+}
+
+// IsRetryable reports whether err is transient and the operation may be
+// attempted again; any other error is permanent. A clean io.EOF, a host that
+// does not resolve and local file errors are permanent.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var re retryableError
+	if errors.As(err, &re) {
+		return re.Retryable()
+	}
+
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = ue.Err
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+
+	// *fs.PathError has a Timeout method and would otherwise pass as a
+	// net.Error below.
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return false
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || isTransientErrno(err) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func newStatusError(resp *http.Response) *apperrors.HTTPStatusError {
+	se := &apperrors.HTTPStatusError{Code: resp.StatusCode, Header: resp.Header}
+	if resp.Request != nil && resp.Request.URL != nil {
+		se.URL = resp.Request.URL.String()
+	}
+	if se.Code == http.StatusTooManyRequests || se.Code == http.StatusServiceUnavailable {
+		se.RetryAfter = parseRetryAfter(resp.Header.Get(HEADER_RETRY_AFTER), time.Now())
+	}
+	return se
+}
+
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,205 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	apperrors "abc/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	RETRY_UNAVAILABLE_PATH = SLASH + "unavailable.txt"
+	RETRY_FORBIDDEN_PATH   = SLASH + "forbidden.txt"
+	RETRY_MID_BODY_PATH    = SLASH + "mid-body.bin"
+	RETRY_DROPPING_PATH    = SLASH + "dropping.bin"
+	RETRY_DROP_AFTER       = 100
+	RETRY_FAILURES         = 2
+)
+
+var (
+	RETRY_BODY_CONTENT = strings.Repeat("retry-body-", 500)
+	RETRY_TEST_POLICY  = RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Jitter:         0.5,
+	}
+)
+
+type RetryTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]int
+	ranges   []string
+}
+
+func TestRetryTestSuite(t *testing.T) {
+	suite.Run(t, new(RetryTestSuite))
+}
+
+func (s *RetryTestSuite) SetupTest() {
+	s.requests = map[string]int{}
+	s.ranges = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.Method+r.URL.Path]++
+		count := s.requests[r.Method+r.URL.Path]
+		if r.Header.Get("Range") != "" {
+			s.ranges = append(s.ranges, r.Header.Get("Range"))
+		}
+		s.mu.Unlock()
+
+		switch r.URL.Path {
+		case RETRY_UNAVAILABLE_PATH:
+			if count <= RETRY_FAILURES {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			io.WriteString(w, GET_FILE_CONTENT)
+
+		case RETRY_DROPPING_PATH:
+			var content io.ReadSeeker = strings.NewReader(RETRY_BODY_CONTENT)
+			if r.Method == http.MethodGet {
+				content = &flakyReadSeeker{ReadSeeker: content, failAfter: RETRY_DROP_AFTER}
+			}
+			w.Header().Set("ETag", `"retry-v1"`)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, "dropping.bin", time.Time{}, content)
+
+		case RETRY_FORBIDDEN_PATH:
+			w.WriteHeader(http.StatusForbidden)
+
+		case RETRY_MID_BODY_PATH:
+			var content io.ReadSeeker = strings.NewReader(RETRY_BODY_CONTENT)
+			if r.Method == http.MethodGet && count == 1 {
+				content = &flakyReadSeeker{ReadSeeker: content, failAfter: 1000}
+			}
+			w.Header().Set("ETag", `"retry-v1"`)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, "mid-body.bin", time.Time{}, content)
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func (s *RetryTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *RetryTestSuite) TestNewHTTPReaderShouldRetryUnavailableHead() {
+	r, err := NewHTTPReader(s.server.URL+RETRY_UNAVAILABLE_PATH, WithRetry(RETRY_TEST_POLICY))
+	s.NoError(err)
+	s.NotNil(r)
+	s.Equal(RETRY_FAILURES+1, s.requests[http.MethodHead+RETRY_UNAVAILABLE_PATH])
+}
+
+func (s *RetryTestSuite) TestNewHTTPReaderShouldFailWithoutRetryPolicy() {
+	r, err := NewHTTPReader(s.server.URL + RETRY_UNAVAILABLE_PATH)
+	s.Error(err)
+	s.Nil(r)
+	s.Equal(1, s.requests[http.MethodHead+RETRY_UNAVAILABLE_PATH])
+}
+
+func (s *RetryTestSuite) TestNewHTTPReaderShouldNotRetryFatalStatus() {
+	_, err := NewHTTPReader(s.server.URL+RETRY_FORBIDDEN_PATH, WithRetry(RETRY_TEST_POLICY))
+	var se *apperrors.HTTPStatusError
+	s.ErrorAs(err, &se)
+	s.Equal(http.StatusForbidden, se.Code)
+	s.ErrorIs(err, apperrors.ErrHTTPStatus)
+	s.NotErrorIs(err, apperrors.ErrURLNotExists)
+	s.Equal(1, s.requests[http.MethodHead+RETRY_FORBIDDEN_PATH])
+}
+
+func (s *RetryTestSuite) TestReadShouldResumeFromLastOffsetAfterMidBodyFailure() {
+	r, err := NewHTTPReader(s.server.URL+RETRY_MID_BODY_PATH, WithRetry(RETRY_TEST_POLICY))
+	s.NoError(err)
+
+	bytes, err := io.ReadAll(r)
+	s.NoError(err)
+	s.Equal(RETRY_BODY_CONTENT, string(bytes))
+	s.Equal(2, s.requests[http.MethodGet+RETRY_MID_BODY_PATH])
+	s.Equal([]string{"bytes=1000-"}, s.ranges)
+}
+
+func (s *RetryTestSuite) TestReadShouldLimitMidBodyResumes() {
+	var notified []int
+	policy := RETRY_TEST_POLICY
+	policy.Notify = func(attempt int, delay time.Duration, err error) {
+		notified = append(notified, attempt)
+	}
+	r, err := NewHTTPReader(s.server.URL+RETRY_DROPPING_PATH, WithRetry(policy))
+	s.NoError(err)
+
+	bytes, err := io.ReadAll(r)
+	s.ErrorIs(err, io.ErrUnexpectedEOF)
+	s.Len(bytes, policy.MaxAttempts*RETRY_DROP_AFTER)
+	s.Equal(policy.MaxAttempts, s.requests[http.MethodGet+RETRY_DROPPING_PATH])
+	s.Equal([]int{1, 2, 3}, notified)
+}
+
+func (s *RetryTestSuite) TestReadShouldFailMidBodyWithoutRetryPolicy() {
+	r, err := NewHTTPReader(s.server.URL + RETRY_MID_BODY_PATH)
+	s.NoError(err)
+
+	_, err = io.ReadAll(r)
+	s.ErrorIs(err, io.ErrUnexpectedEOF)
+}
+
+func (s *RetryTestSuite) TestIsRetryableShouldClassifyErrors() {
+	s.True(IsRetryable(io.ErrUnexpectedEOF))
+	s.True(IsRetryable(&apperrors.HTTPStatusError{Code: http.StatusServiceUnavailable}))
+	s.True(IsRetryable(&apperrors.HTTPStatusError{Code: http.StatusTooManyRequests}))
+	s.True(IsRetryable(fmt.Errorf("probe: %w", &apperrors.HTTPStatusError{Code: http.StatusBadGateway})))
+	s.False(IsRetryable(&apperrors.HTTPStatusError{Code: http.StatusNotFound}))
+	s.False(IsRetryable(&apperrors.HTTPStatusError{Code: http.StatusNotImplemented}))
+	s.False(IsRetryable(&apperrors.SourceNotFoundError{Path: FILE_LOCAL_NAME, Err: fs.ErrNotExist}))
+	s.False(IsRetryable(&apperrors.UnsupportedSchemeError{Scheme: "ftp"}))
+	s.False(IsRetryable(errors.New("boom")))
+	s.False(IsRetryable(io.EOF))
+	s.False(IsRetryable(&url.Error{Op: "Get", Err: &net.DNSError{Name: "nope.invalid", IsNotFound: true}}))
+	s.True(IsRetryable(&net.DNSError{Name: "slow.example", IsTimeout: true}))
+	s.False(IsRetryable(&fs.PathError{Op: "read", Path: FILE_LOCAL_NAME, Err: syscall.EISDIR}))
+	s.False(IsRetryable(nil))
+}
+
+func (s *RetryTestSuite) TestParseRetryAfterShouldSupportSecondsAndDates() {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Equal(5*time.Second, parseRetryAfter("5", now))
+	s.Equal(30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	s.Equal(time.Duration(0), parseRetryAfter("garbage", now))
+	s.Equal(time.Duration(0), parseRetryAfter("", now))
+}
+
+func (s *RetryTestSuite) TestDelayShouldGrowExponentiallyAndHonorRetryAfter() {
+	policy := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond}
+	s.Equal(10*time.Millisecond, policy.delay(1, nil))
+	s.Equal(20*time.Millisecond, policy.delay(2, nil))
+	s.Equal(40*time.Millisecond, policy.delay(4, nil))
+
+	se := &apperrors.HTTPStatusError{Code: http.StatusTooManyRequests, RetryAfter: 30 * time.Millisecond}
+	s.Equal(30*time.Millisecond, policy.delay(1, se))
+
+	se.RetryAfter = time.Second
+	s.Equal(40*time.Millisecond, policy.delay(1, se))
+
+	policy.MaxBackoff = 0
+	s.Equal(time.Second, policy.delay(1, se))
+}
@@ -0,0 +1,206 @@
+package reader
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	apperrors "abc/errors"
+)
+
+const (
+	SCHEME_S3 = "s3"
+
+	S3_SERVICE         = "s3"
+	S3_DEFAULT_REGION  = "us-east-1"
+	S3_DEFAULT_PROFILE = "default"
+
+	ENV_AWS_ACCESS_KEY_ID      = "AWS_ACCESS_KEY_ID"
+	ENV_AWS_SECRET_ACCESS_KEY  = "AWS_SECRET_ACCESS_KEY"
+	ENV_AWS_SESSION_TOKEN      = "AWS_SESSION_TOKEN"
+	ENV_AWS_REGION             = "AWS_REGION"
+	ENV_AWS_DEFAULT_REGION     = "AWS_DEFAULT_REGION"
+	ENV_AWS_PROFILE            = "AWS_PROFILE"
+	ENV_AWS_ENDPOINT_URL       = "AWS_ENDPOINT_URL"
+	ENV_AWS_ENDPOINT_URL_S3    = "AWS_ENDPOINT_URL_S3"
+	ENV_AWS_SHARED_CREDENTIALS = "AWS_SHARED_CREDENTIALS_FILE"
+	ENV_AWS_CONFIG_FILE        = "AWS_CONFIG_FILE"
+)
+
+type S3Config struct {
+	Endpoint    string
+	Region      string
+	Credentials *Credentials
+	PathStyle   bool
+}
+
+type S3Reader struct {
+	*HTTPReader
+	bucket string
+	key    string
+}
+
+func (r *S3Reader) Filename() string {
+	return path.Base(r.key)
+}
+
+func (r *S3Reader) Stat() SourceInfo {
+	info := r.HTTPReader.Stat()
+	info.Filename = r.Filename()
+	return info
+}
+
+func newS3Source(u *url.URL, opts Options) (SourceReader, error) {
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if u.Opaque != "" {
+		bucket, key, _ = strings.Cut(u.Opaque, "/")
+	}
+	if bucket == "" || key == "" {
+		return nil, apperrors.ErrInvalidS3URL
+	}
+
+	config := resolveS3Config(opts.S3)
+	objectURL, err := s3ObjectURL(config, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	base := opts.Transport
+	if base == nil {
+		base = newDefaultTransport()
+	}
+	opts.Transport = &sigV4Transport{
+		base: base,
+		signer: &sigV4Signer{
+			credentials: *config.Credentials,
+			region:      config.Region,
+			service:     S3_SERVICE,
+			now:         time.Now,
+		},
+	}
+
+	httpReader, err := newHTTPReader(objectURL.String(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Reader{HTTPReader: httpReader, bucket: bucket, key: key}, nil
+}
+
+func resolveS3Config(config S3Config) S3Config {
+	profile := firstNonEmpty(os.Getenv(ENV_AWS_PROFILE), S3_DEFAULT_PROFILE)
+
+	if config.Endpoint == "" {
+		config.Endpoint = firstNonEmpty(os.Getenv(ENV_AWS_ENDPOINT_URL_S3), os.Getenv(ENV_AWS_ENDPOINT_URL))
+	}
+
+	if config.Region == "" {
+		config.Region = firstNonEmpty(
+			os.Getenv(ENV_AWS_REGION),
+			os.Getenv(ENV_AWS_DEFAULT_REGION),
+			readAWSConfigValue(awsConfigPath(ENV_AWS_CONFIG_FILE, "config"), "profile "+profile, "region"),
+			S3_DEFAULT_REGION,
+		)
+	}
+
+	if config.Credentials == nil {
+		config.Credentials = &Credentials{
+			AccessKeyID:     os.Getenv(ENV_AWS_ACCESS_KEY_ID),
+			SecretAccessKey: os.Getenv(ENV_AWS_SECRET_ACCESS_KEY),
+			SessionToken:    os.Getenv(ENV_AWS_SESSION_TOKEN),
+		}
+	}
+	if config.Credentials.AccessKeyID == "" {
+		credentialsPath := awsConfigPath(ENV_AWS_SHARED_CREDENTIALS, "credentials")
+		config.Credentials = &Credentials{
+			AccessKeyID:     readAWSConfigValue(credentialsPath, profile, "aws_access_key_id"),
+			SecretAccessKey: readAWSConfigValue(credentialsPath, profile, "aws_secret_access_key"),
+			SessionToken:    readAWSConfigValue(credentialsPath, profile, "aws_session_token"),
+		}
+	}
+
+	return config
+}
+
+func s3ObjectURL(config S3Config, bucket, key string) (*url.URL, error) {
+	endpoint := config.Endpoint
+	pathStyle := config.PathStyle || endpoint != "" || strings.Contains(bucket, ".")
+	if endpoint == "" {
+		endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	objectPath := "/" + key
+	if pathStyle {
+		objectPath = "/" + bucket + objectPath
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + objectPath
+	u.RawPath = s3EscapePath(u.Path)
+	return u, nil
+}
+
+func awsConfigPath(env string, name string) string {
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// readAWSConfigValue reads a key from an INI-style AWS config or credentials
+// file. Sections may be written as [name] or [profile name].
+func readAWSConfigValue(configPath string, section string, key string) string {
+	if configPath == "" {
+		return ""
+	}
+	file, err := os.Open(configPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	wanted := strings.TrimPrefix(section, "profile ")
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(strings.Trim(line, "[]"))
+			inSection = strings.TrimPrefix(name, "profile ") == wanted
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
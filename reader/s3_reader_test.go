@@ -0,0 +1,167 @@
+package reader
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	S3_TEST_BUCKET       = "releases"
+	S3_TEST_KEY          = "nightly/build artifact.tar"
+	S3_TEST_FILE_NAME    = "build artifact.tar"
+	S3_TEST_OBJECT_PATH  = SLASH + S3_TEST_BUCKET + SLASH + "nightly/build%20artifact.tar"
+	S3_TEST_SOURCE       = "s3://" + S3_TEST_BUCKET + SLASH + S3_TEST_KEY
+	S3_TEST_ACCESS_KEY   = "AKIDTEST"
+	S3_TEST_SECRET_KEY   = "secret"
+	S3_TEST_REGION       = "eu-central-1"
+	S3_TEST_CREDENTIAL   = "Credential=" + S3_TEST_ACCESS_KEY + "/"
+	S3_TEST_SCOPE_SUFFIX = "/" + S3_TEST_REGION + "/s3/aws4_request"
+)
+
+var S3_TEST_CONTENT = strings.Repeat("s3-object-", 200)
+
+type S3ReaderTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	requests []*http.Request
+}
+
+func TestS3ReaderTestSuite(t *testing.T) {
+	suite.Run(t, new(S3ReaderTestSuite))
+}
+
+func (s *S3ReaderTestSuite) SetupTest() {
+	s.requests = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests = append(s.requests, r.Clone(r.Context()))
+
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, SIGV4_ALGORITHM+" "+S3_TEST_CREDENTIAL) || !strings.Contains(auth, S3_TEST_SCOPE_SUFFIX) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.EscapedPath() != S3_TEST_OBJECT_PATH {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("ETag", `"s3-etag"`)
+		w.Header().Set("Content-Type", "application/x-tar")
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(S3_TEST_CONTENT))
+	}))
+
+	s.T().Setenv(ENV_AWS_ACCESS_KEY_ID, S3_TEST_ACCESS_KEY)
+	s.T().Setenv(ENV_AWS_SECRET_ACCESS_KEY, S3_TEST_SECRET_KEY)
+	s.T().Setenv(ENV_AWS_SESSION_TOKEN, "")
+	s.T().Setenv(ENV_AWS_REGION, S3_TEST_REGION)
+	s.T().Setenv(ENV_AWS_ENDPOINT_URL_S3, s.server.URL)
+}
+
+func (s *S3ReaderTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *S3ReaderTestSuite) TestNewReaderShouldSignHeadObjectAndDeriveMetadata() {
+	r, err := NewReader(S3_TEST_SOURCE)
+	s.NoError(err)
+	s.NotNil(r)
+
+	s.Equal(S3_TEST_FILE_NAME, r.src.Filename())
+	s.Equal(int64(len(S3_TEST_CONTENT)), r.src.TotalSize())
+	s.Equal(http.MethodHead, s.requests[0].Method)
+	s.NotEmpty(s.requests[0].Header.Get("X-Amz-Date"))
+	s.Equal(SIGV4_EMPTY_SHA256, s.requests[0].Header.Get("X-Amz-Content-Sha256"))
+}
+
+func (s *S3ReaderTestSuite) TestStreamToFileShouldDownloadObject() {
+	r, err := NewReader(S3_TEST_SOURCE)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	path, n, err := r.StreamToFile(dest)
+	s.NoError(err)
+	s.Equal(S3_TEST_FILE_NAME, filepath.Base(path))
+	s.Equal(int64(len(S3_TEST_CONTENT)), n)
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(S3_TEST_CONTENT, string(data))
+}
+
+func (s *S3ReaderTestSuite) TestStreamToFileShouldResumeWithSignedRangedGet() {
+	r, err := NewReader(S3_TEST_SOURCE)
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	s.NoError(os.WriteFile(r.partFilePath(dest), []byte(S3_TEST_CONTENT[:100]), 0o644))
+
+	path, _, err := r.StreamToFile(dest)
+	s.NoError(err)
+
+	last := s.requests[len(s.requests)-1]
+	s.Equal("bytes=100-", last.Header.Get("Range"))
+	s.Contains(last.Header.Get("Authorization"), "range")
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(S3_TEST_CONTENT, string(data))
+}
+
+func (s *S3ReaderTestSuite) TestNewReaderShouldFailWithoutCredentials() {
+	s.T().Setenv(ENV_AWS_ACCESS_KEY_ID, "")
+	s.T().Setenv(ENV_AWS_SHARED_CREDENTIALS, filepath.Join(s.T().TempDir(), "missing"))
+
+	r, err := NewReader(S3_TEST_SOURCE)
+	s.Nil(r)
+	s.Error(err)
+}
+
+func (s *S3ReaderTestSuite) TestNewReaderShouldReadSharedCredentialsFile() {
+	credentials := filepath.Join(s.T().TempDir(), "credentials")
+	content := "[default]\naws_access_key_id = nope\n\n[ci]\naws_access_key_id = " + S3_TEST_ACCESS_KEY +
+		"\naws_secret_access_key = " + S3_TEST_SECRET_KEY + "\n"
+	s.NoError(os.WriteFile(credentials, []byte(content), 0o600))
+
+	s.T().Setenv(ENV_AWS_ACCESS_KEY_ID, "")
+	s.T().Setenv(ENV_AWS_PROFILE, "ci")
+	s.T().Setenv(ENV_AWS_SHARED_CREDENTIALS, credentials)
+
+	r, err := NewReader(S3_TEST_SOURCE)
+	s.NoError(err)
+
+	data, err := io.ReadAll(r)
+	s.NoError(err)
+	s.Equal(S3_TEST_CONTENT, string(data))
+}
+
+func (s *S3ReaderTestSuite) TestNewReaderShouldKeepKeyCharactersLiterally() {
+	_, err := NewReader("s3://" + S3_TEST_BUCKET + "/notes/v1#draft?.txt")
+	s.Error(err)
+	s.Require().NotEmpty(s.requests)
+	s.Equal(SLASH+S3_TEST_BUCKET+"/notes/v1%23draft%3F.txt", s.requests[0].URL.EscapedPath())
+	s.Empty(s.requests[0].URL.RawQuery)
+}
+
+func (s *S3ReaderTestSuite) TestNewReaderShouldRejectURLWithoutKey() {
+	r, err := NewReader("s3://" + S3_TEST_BUCKET)
+	s.Nil(r)
+	s.Equal("invalid s3 url, expected s3://bucket/key", err.Error())
+}
+
+func (s *S3ReaderTestSuite) TestS3ObjectURLShouldUseVirtualHostedStyleOnAWS() {
+	u, err := s3ObjectURL(S3Config{Region: S3_TEST_REGION}, S3_TEST_BUCKET, "a/b.txt")
+	s.NoError(err)
+	s.Equal("https://releases.s3.eu-central-1.amazonaws.com/a/b.txt", u.String())
+
+	u, err = s3ObjectURL(S3Config{Region: S3_TEST_REGION}, "my.dotted.bucket", "b.txt")
+	s.NoError(err)
+	s.Equal("https://s3.eu-central-1.amazonaws.com/my.dotted.bucket/b.txt", u.String())
+}
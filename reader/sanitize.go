@@ -0,0 +1,101 @@
+package reader
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MAX_FILENAME_BYTES = 255
+
+	UNSAFE_FILENAME_CHARS = `<>:"/\|?*`
+	FILENAME_REPLACEMENT  = '_'
+)
+
+var reservedFilenames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename turns a server-controlled name into a single safe path
+// element. Directory components are dropped, control and reserved characters
+// are replaced, and over-long names are shortened keeping the extension. The
+// second result reports whether the name was altered.
+func SanitizeFilename(name string) (string, bool) {
+	original := name
+
+	name = strings.ReplaceAll(name, `\`, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.ToValidUTF8(name, string(FILENAME_REPLACEMENT))
+	name = strings.Map(func(c rune) rune {
+		// format characters such as U+202E reorder text and can disguise
+		// the real extension
+		if unicode.IsControl(c) || unicode.Is(unicode.Cf, c) || strings.ContainsRune(UNSAFE_FILENAME_CHARS, c) {
+			return FILENAME_REPLACEMENT
+		}
+		return c
+	}, name)
+
+	// leading dots would hide the file, trailing dots and spaces are
+	// silently dropped by some filesystems
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		name = DEFAULT_FILENAME
+	}
+
+	stem, _, _ := strings.Cut(name, ".")
+	if reservedFilenames[strings.ToUpper(strings.TrimSpace(stem))] {
+		name = string(FILENAME_REPLACEMENT) + name
+	}
+
+	// truncating may expose a trailing dot or space again
+	name = strings.Trim(truncateFilename(name, MAX_FILENAME_BYTES), ". ")
+	if name == "" {
+		name = DEFAULT_FILENAME
+	}
+	return name, name != original
+}
+
+func truncateFilename(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	if len(ext) >= max {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(name, ext)
+
+	limit := max - len(ext)
+	for limit > 0 && !utf8.RuneStart(stem[limit]) {
+		limit--
+	}
+	return strings.TrimRight(stem[:limit], ". ") + ext
+}
+
+// isWithinFolder reports whether path resolves to a location inside folder.
+func isWithinFolder(folder, path string) bool {
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return false
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(folder, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
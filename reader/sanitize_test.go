@@ -0,0 +1,129 @@
+package reader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		altered bool
+	}{
+		{name: "report.pdf", want: "report.pdf"},
+		{name: "€ rates.txt", want: "€ rates.txt"},
+		{name: "../../etc/cron.d/x", want: "x", altered: true},
+		{name: `..\..\windows\system32\evil.dll`, want: "evil.dll", altered: true},
+		{name: "/etc/passwd", want: "passwd", altered: true},
+		{name: "..", want: DEFAULT_FILENAME, altered: true},
+		{name: "", want: DEFAULT_FILENAME, altered: true},
+		{name: ".bashrc", want: "bashrc", altered: true},
+		{name: "trailing. ", want: "trailing", altered: true},
+		{name: "nul\x00byte.txt", want: "nul_byte.txt", altered: true},
+		{name: "line\nbreak\r.txt", want: "line_break_.txt", altered: true},
+		{name: "what?<now>.txt", want: "what__now_.txt", altered: true},
+		{name: "bad\xffutf8.txt", want: "bad_utf8.txt", altered: true},
+		{name: "CON", want: "_CON", altered: true},
+		{name: "com1.log", want: "_com1.log", altered: true},
+		{name: "console.log", want: "console.log"},
+		{name: "invoice\u202Etxt.exe", want: "invoice_txt.exe", altered: true},
+		{name: "zero\u200Bwidth.txt", want: "zero_width.txt", altered: true},
+	}
+
+	for _, tt := range tests {
+		got, altered := SanitizeFilename(tt.name)
+		if got != tt.want || altered != tt.altered {
+			t.Fatalf("%q: want (%q, %v) got (%q, %v)", tt.name, tt.want, tt.altered, got, altered)
+		}
+	}
+}
+
+func TestSanitizeFilename_TruncatesKeepingExtension(t *testing.T) {
+	name := strings.Repeat("é", 200) + ".tar.gz"
+
+	got, altered := SanitizeFilename(name)
+	if !altered {
+		t.Fatalf("expected long name to be reported as altered")
+	}
+	if len(got) > MAX_FILENAME_BYTES {
+		t.Fatalf("expected at most %d bytes, got %d", MAX_FILENAME_BYTES, len(got))
+	}
+	if !strings.HasSuffix(got, ".gz") {
+		t.Fatalf("expected extension to be kept, got %q", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Fatalf("expected truncation on a rune boundary, got %q", got)
+	}
+}
+
+func TestSanitizeFilename_TrimsAfterTruncating(t *testing.T) {
+	for _, name := range []string{
+		strings.Repeat("a", MAX_FILENAME_BYTES-1) + ". " + strings.Repeat("b", 10),
+		strings.Repeat("a", MAX_FILENAME_BYTES-2) + " ." + strings.Repeat("e", MAX_FILENAME_BYTES),
+		strings.Repeat("a", MAX_FILENAME_BYTES-8) + " ..." + strings.Repeat("b", 40) + ".txt",
+	} {
+		got, _ := SanitizeFilename(name)
+		if len(got) > MAX_FILENAME_BYTES {
+			t.Fatalf("expected at most %d bytes, got %d", MAX_FILENAME_BYTES, len(got))
+		}
+		stem := strings.TrimSuffix(got, filepath.Ext(got))
+		if strings.HasSuffix(got, ".") || strings.HasSuffix(got, " ") ||
+			strings.HasSuffix(stem, ".") || strings.HasSuffix(stem, " ") {
+			t.Fatalf("expected no dot or space at the cut, got %q", got)
+		}
+	}
+}
+
+func TestIsWithinFolder(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]bool{
+		filepath.Join(dir, "file.txt"):             true,
+		filepath.Join(dir, "..", "file.txt"):       false,
+		filepath.Join(dir, "sub", "..", "..", "x"): false,
+		dir: false,
+	}
+	for path, want := range tests {
+		if got := isWithinFolder(dir, path); got != want {
+			t.Fatalf("%q: want %v got %v", path, want, got)
+		}
+	}
+}
+
+func TestSave_ShouldKeepTraversalNameInsideDestination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="../../escape.txt"`)
+		w.Write([]byte(FILE_LOCAL_CONTENT))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "dest")
+	if err := os.Mkdir(dest, 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := NewReader(server.URL + "/download")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := r.Save(dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Path != filepath.Join(dest, "escape.txt") {
+		t.Fatalf("expected file inside destination, got %q", result.Path)
+	}
+	if !result.FilenameAltered || result.OriginalFilename != "../../escape.txt" {
+		t.Fatalf("expected altered name to be reported, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing written outside destination")
+	}
+}
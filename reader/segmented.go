@@ -0,0 +1,109 @@
+package reader
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+const SEGMENT_MAX_ATTEMPTS = 3
+
+// SegmentedSourceReader is implemented by sources that can serve arbitrary
+// byte ranges, allowing StreamToFile to fetch several of them concurrently.
+type SegmentedSourceReader interface {
+	SourceReader
+	SegmentCount() int
+	OpenRange(start, end int64) (io.ReadCloser, error)
+}
+
+type segment struct {
+	start int64
+	end   int64
+}
+
+func splitSegments(totalSize int64, count int) []segment {
+	if int64(count) > totalSize {
+		count = int(totalSize)
+	}
+	size := totalSize / int64(count)
+
+	segments := make([]segment, 0, count)
+	for i := 0; i < count; i++ {
+		start := int64(i) * size
+		end := start + size - 1
+		if i == count-1 {
+			end = totalSize - 1
+		}
+		segments = append(segments, segment{start: start, end: end})
+	}
+	return segments
+}
+
+func downloadSegments(out io.WriterAt, src SegmentedSourceReader, pr *ProgressReader) (int64, error) {
+	segments := splitSegments(src.TotalSize(), src.SegmentCount())
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		written int64
+		errs    []error
+	)
+
+	for _, seg := range segments {
+		wg.Add(1)
+		go func(seg segment) {
+			defer wg.Done()
+			n, err := downloadSegment(out, src, pr, seg)
+
+			mu.Lock()
+			defer mu.Unlock()
+			written += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(seg)
+	}
+	wg.Wait()
+
+	return written, errors.Join(errs...)
+}
+
+func downloadSegment(out io.WriterAt, src SegmentedSourceReader, pr *ProgressReader, seg segment) (int64, error) {
+	var (
+		done    int64
+		lastErr error
+	)
+	length := seg.end - seg.start + 1
+
+	for attempt := 0; attempt < SEGMENT_MAX_ATTEMPTS && done < length; attempt++ {
+		body, err := src.OpenRange(seg.start+done, seg.end)
+		if err != nil {
+			lastErr = err
+			if !IsRetryable(err) {
+				break
+			}
+			continue
+		}
+
+		w := io.NewOffsetWriter(out, seg.start+done)
+		n, err := io.Copy(w, io.LimitReader(pr.Segment(body), length-done))
+		body.Close()
+		done += n
+
+		if err != nil {
+			lastErr = err
+			if !IsRetryable(err) {
+				break
+			}
+			continue
+		}
+		if done < length {
+			lastErr = io.ErrUnexpectedEOF
+		}
+	}
+
+	if done < length {
+		return done, lastErr
+	}
+	return done, nil
+}
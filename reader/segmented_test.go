@@ -0,0 +1,189 @@
+package reader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apperrors "abc/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	SEG_FILE_NAME       = "segmented.bin"
+	SEG_FILE_PATH       = SLASH + SEG_FILE_NAME
+	SEG_FLAKY_FILE_NAME = "flaky.bin"
+	SEG_FLAKY_FILE_PATH = SLASH + SEG_FLAKY_FILE_NAME
+	SEG_COUNT           = 4
+)
+
+var SEG_FILE_CONTENT = strings.Repeat("segmented-download-", 1000)
+
+type flakyReadSeeker struct {
+	io.ReadSeeker
+	failAfter int
+	read      int
+}
+
+func (f *flakyReadSeeker) Read(p []byte) (int, error) {
+	if f.read >= f.failAfter {
+		return 0, errors.New("flaky read")
+	}
+	if len(p) > f.failAfter-f.read {
+		p = p[:f.failAfter-f.read]
+	}
+	n, err := f.ReadSeeker.Read(p)
+	f.read += n
+	return n, err
+}
+
+type SegmentedTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+
+	mu            sync.Mutex
+	rangeRequests []string
+	flakyFailed   bool
+}
+
+func TestSegmentedTestSuite(t *testing.T) {
+	suite.Run(t, new(SegmentedTestSuite))
+}
+
+func (s *SegmentedTestSuite) SetupTest() {
+	s.rangeRequests = nil
+	s.flakyFailed = false
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.Header.Get("Range") != "" {
+			s.mu.Lock()
+			s.rangeRequests = append(s.rangeRequests, r.Header.Get("Range"))
+			s.mu.Unlock()
+		}
+
+		var content io.ReadSeeker = strings.NewReader(SEG_FILE_CONTENT)
+		switch r.URL.Path {
+		case SEG_FILE_PATH:
+		case SEG_FLAKY_FILE_PATH:
+			s.mu.Lock()
+			if r.Method == http.MethodGet && r.Header.Get("Range") != "" && !s.flakyFailed {
+				s.flakyFailed = true
+				content = &flakyReadSeeker{ReadSeeker: content, failAfter: 100}
+			}
+			s.mu.Unlock()
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"seg-v1"`)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, SEG_FILE_NAME, time.Time{}, content)
+	}))
+}
+
+func (s *SegmentedTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *SegmentedTestSuite) TestSplitSegmentsShouldCoverWholeFile() {
+	segments := splitSegments(10, 3)
+	s.Equal([]segment{{0, 2}, {3, 5}, {6, 9}}, segments)
+}
+
+func (s *SegmentedTestSuite) TestSplitSegmentsShouldNotExceedTotalSize() {
+	segments := splitSegments(2, 8)
+	s.Equal([]segment{{0, 0}, {1, 1}}, segments)
+}
+
+func (s *SegmentedTestSuite) TestSegmentCountShouldBeZeroWithoutOptIn() {
+	r, err := NewHTTPReader(s.server.URL + SEG_FILE_PATH)
+	s.NoError(err)
+	s.Equal(0, r.SegmentCount())
+}
+
+func (s *SegmentedTestSuite) TestSegmentCountShouldBeZeroWithoutRangeSupport() {
+	r := &HTTPReader{segments: SEG_COUNT, totalSize: 100}
+	s.Equal(0, r.SegmentCount())
+}
+
+func (s *SegmentedTestSuite) TestStreamToFileShouldDownloadSegmentsConcurrently() {
+	r, err := NewReader(s.server.URL+SEG_FILE_PATH, WithSegments(SEG_COUNT))
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	path, n, err := r.StreamToFile(dest)
+	s.NoError(err)
+	s.Equal(int64(len(SEG_FILE_CONTENT)), n)
+	s.Len(s.rangeRequests, SEG_COUNT)
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(SEG_FILE_CONTENT, string(data))
+}
+
+func (s *SegmentedTestSuite) TestStreamToFileShouldRetryFailedSegment() {
+	r, err := NewReader(s.server.URL+SEG_FLAKY_FILE_PATH, WithSegments(SEG_COUNT))
+	s.NoError(err)
+
+	dest := s.T().TempDir()
+	path, n, err := r.StreamToFile(dest)
+	s.NoError(err)
+	s.Equal(int64(len(SEG_FILE_CONTENT)), n)
+	s.Len(s.rangeRequests, SEG_COUNT+1)
+
+	data, readErr := os.ReadFile(path)
+	s.NoError(readErr)
+	s.Equal(SEG_FILE_CONTENT, string(data))
+}
+
+func (s *SegmentedTestSuite) TestSegmentWriteFailuresShouldBeDestinationErrors() {
+	r, err := NewReader(s.server.URL+SEG_FILE_PATH, WithSegments(SEG_COUNT))
+	s.Require().NoError(err)
+	segmented, ok := r.segmentedSource()
+	s.Require().True(ok)
+
+	pr := r.progressReader(context.Background(), StreamOptions{}, 0)
+	_, err = downloadSegments(destinationFile{readOnlyFile(s.T())}, segmented, pr)
+	var destErr *apperrors.DestinationError
+	s.True(errors.As(err, &destErr))
+	s.Len(s.rangeRequests, SEG_COUNT, "write failures should not be retried")
+}
+
+func (s *SegmentedTestSuite) TestProgressReaderShouldAggregateSegments() {
+	var last int64
+	pr := &ProgressReader{
+		TotalSize: 6,
+		Notify: func(n int64, totalSize int64) {
+			last = n
+		},
+	}
+
+	_, err := io.ReadAll(pr.Segment(strings.NewReader("abc")))
+	s.NoError(err)
+	_, err = io.ReadAll(pr.Segment(strings.NewReader("def")))
+	s.NoError(err)
+	s.Equal(int64(6), last)
+	s.Equal(int64(6), pr.ReadSize)
+}
+
+func (s *SegmentedTestSuite) TestStreamToFileShouldVerifySegmentedDownload() {
+	sum := sha256.Sum256([]byte(SEG_FILE_CONTENT))
+	r, err := NewReader(s.server.URL+SEG_FILE_PATH, WithSegments(SEG_COUNT))
+	s.NoError(err)
+
+	_, _, err = r.StreamToFile(s.T().TempDir(), WithChecksum(CHECKSUM_SHA256, hex.EncodeToString(sum[:])))
+	s.NoError(err)
+
+	_, _, err = r.StreamToFile(s.T().TempDir(), WithChecksum(CHECKSUM_SHA256, CHECKSUM_WRONG_DIGEST))
+	var mismatch *ChecksumMismatchError
+	s.ErrorAs(err, &mismatch)
+}
@@ -0,0 +1,181 @@
+package reader
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SIGV4_ALGORITHM       = "AWS4-HMAC-SHA256"
+	SIGV4_TERMINATOR      = "aws4_request"
+	SIGV4_DATE_FORMAT     = "20060102"
+	SIGV4_DATETIME_FORMAT = "20060102T150405Z"
+	SIGV4_EMPTY_SHA256    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	HEADER_AUTHORIZATION        = "Authorization"
+	HEADER_AMZ_DATE             = "X-Amz-Date"
+	HEADER_AMZ_CONTENT_SHA256   = "X-Amz-Content-Sha256"
+	HEADER_AMZ_SECURITY_TOKEN   = "X-Amz-Security-Token"
+	SIGV4_SIGNED_HEADER_PREFIX  = "x-amz-"
+	SIGV4_SIGNED_HEADER_RANGE   = "range"
+	SIGV4_SIGNED_HEADER_IFRANGE = "if-range"
+)
+
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+type sigV4Signer struct {
+	credentials Credentials
+	region      string
+	service     string
+	now         func() time.Time
+}
+
+type sigV4Transport struct {
+	base   http.RoundTripper
+	signer *sigV4Signer
+}
+
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+	t.signer.sign(signed)
+	return t.base.RoundTrip(signed)
+}
+
+func (s *sigV4Signer) sign(req *http.Request) {
+	if s.credentials.AccessKeyID == "" {
+		return
+	}
+
+	now := s.now().UTC()
+	req.Header.Set(HEADER_AMZ_DATE, now.Format(SIGV4_DATETIME_FORMAT))
+	req.Header.Set(HEADER_AMZ_CONTENT_SHA256, SIGV4_EMPTY_SHA256)
+	if s.credentials.SessionToken != "" {
+		req.Header.Set(HEADER_AMZ_SECURITY_TOKEN, s.credentials.SessionToken)
+	}
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		SIGV4_EMPTY_SHA256,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(SIGV4_DATE_FORMAT), s.region, s.service, SIGV4_TERMINATOR}, "/")
+	stringToSign := strings.Join([]string{
+		SIGV4_ALGORITHM,
+		now.Format(SIGV4_DATETIME_FORMAT),
+		scope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.credentials.SecretAccessKey), now.Format(SIGV4_DATE_FORMAT))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, SIGV4_TERMINATOR)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set(HEADER_AUTHORIZATION, SIGV4_ALGORITHM+
+		" Credential="+s.credentials.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+}
+
+func canonicalHeaders(req *http.Request) (string, string) {
+	values := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		values["host"] = req.Host
+	}
+	for name, vals := range req.Header {
+		lower := strings.ToLower(name)
+		if !strings.HasPrefix(lower, SIGV4_SIGNED_HEADER_PREFIX) &&
+			lower != SIGV4_SIGNED_HEADER_RANGE && lower != SIGV4_SIGNED_HEADER_IFRANGE {
+			continue
+		}
+		trimmed := make([]string, 0, len(vals))
+		for _, v := range vals {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		values[lower] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vals := append([]string(nil), query[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3EscapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return s3Escape(path, false)
+}
+
+func s3Escape(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
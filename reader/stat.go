@@ -0,0 +1,98 @@
+package reader
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var compressionContentTypes = map[string]CompressionFormat{
+	"application/gzip":     COMPRESSION_GZIP,
+	"application/x-gzip":   COMPRESSION_GZIP,
+	"application/x-bzip2":  COMPRESSION_BZIP2,
+	"application/x-xz":     COMPRESSION_XZ,
+	"application/zstd":     COMPRESSION_ZSTD,
+	"application/x-lz4":    COMPRESSION_LZ4,
+	"application/x-brotli": COMPRESSION_BROTLI,
+	"application/zlib":     COMPRESSION_ZLIB,
+}
+
+// SourceInfo describes a source without transferring its content. URL is
+// where the source was finally found, after any redirects. Size is
+// non-positive when unknown. Compression is the format of the content
+// itself, while ContentEncoding is the coding applied only for transfer.
+type SourceInfo struct {
+	Source          string            `json:"source"`
+	URL             string            `json:"url,omitempty"`
+	Filename        string            `json:"filename"`
+	Size            int64             `json:"size"`
+	ContentType     string            `json:"content_type,omitempty"`
+	LastModified    time.Time         `json:"last_modified,omitzero"`
+	ETag            string            `json:"etag,omitempty"`
+	AcceptRanges    bool              `json:"accept_ranges"`
+	Compression     CompressionFormat `json:"compression,omitempty"`
+	ContentEncoding string            `json:"content_encoding,omitempty"`
+}
+
+// StatSourceReader is implemented by sources that know more about
+// themselves than their name and size.
+type StatSourceReader interface {
+	SourceReader
+	Stat() SourceInfo
+}
+
+// Stat resolves source the way NewReader does and describes it without
+// reading its content.
+func Stat(source string, opts ...Option) (SourceInfo, error) {
+	return StatContext(context.Background(), source, opts...)
+}
+
+func StatContext(ctx context.Context, source string, opts ...Option) (SourceInfo, error) {
+	r, err := NewReaderContext(ctx, source, opts...)
+	if err != nil {
+		return SourceInfo{}, err
+	}
+	defer r.Close()
+	return r.Stat(), nil
+}
+
+func (r *Reader) Stat() SourceInfo {
+	var info SourceInfo
+	if r.src == nil {
+		return info
+	}
+	if ss, ok := r.src.(StatSourceReader); ok {
+		info = ss.Stat()
+	} else {
+		info = SourceInfo{Filename: r.src.Filename(), Size: r.src.TotalSize()}
+	}
+	info.Source = r.source
+	if info.URL == "" {
+		info.URL = r.source
+	}
+	if info.Compression == COMPRESSION_NONE {
+		info.Compression = detectCompressionByExtension(info.Filename)
+	}
+	return info
+}
+
+func compressionForContentType(contentType string) CompressionFormat {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return COMPRESSION_NONE
+	}
+	return compressionContentTypes[strings.ToLower(mediaType)]
+}
+
+func parseHTTPTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
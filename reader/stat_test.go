@@ -0,0 +1,135 @@
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "abc/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	STAT_FILE_PATH     = "/files/report.csv"
+	STAT_REDIRECT_PATH = "/latest"
+	STAT_ARCHIVE_PATH  = "/download"
+	STAT_CONTENT       = "a,b,c\n1,2,3\n"
+	STAT_ETAG          = `"stat-v1"`
+	STAT_LAST_MODIFIED = "Wed, 21 Oct 2015 07:28:00 GMT"
+	STAT_CONTENT_TYPE  = "text/csv"
+)
+
+type StatTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	requests []string
+}
+
+func TestStatTestSuite(t *testing.T) {
+	suite.Run(t, new(StatTestSuite))
+}
+
+func (s *StatTestSuite) SetupTest() {
+	s.requests = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case STAT_REDIRECT_PATH:
+			http.Redirect(w, r, STAT_FILE_PATH, http.StatusFound)
+		case STAT_FILE_PATH:
+			w.Header().Set(HEADER_CONTENT_TYPE, STAT_CONTENT_TYPE)
+			w.Header().Set(HEADER_ETAG, STAT_ETAG)
+			w.Header().Set(HEADER_LAST_MODIFIED, STAT_LAST_MODIFIED)
+			w.Header().Set(HEADER_ACCEPT_RANGES, ACCEPT_RANGES_BYTES)
+			w.Write([]byte(STAT_CONTENT))
+		case STAT_ARCHIVE_PATH:
+			w.Header().Set(HEADER_CONTENT_TYPE, "application/gzip")
+			w.Write([]byte(STAT_CONTENT))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func (s *StatTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *StatTestSuite) TestStatShouldDescribeHTTPSourceAfterRedirects() {
+	source := s.server.URL + STAT_REDIRECT_PATH
+	info, err := Stat(source)
+	s.Require().NoError(err)
+
+	lastModified, _ := time.Parse(http.TimeFormat, STAT_LAST_MODIFIED)
+	s.Equal(SourceInfo{
+		Source:       source,
+		URL:          s.server.URL + STAT_FILE_PATH,
+		Filename:     "report.csv",
+		Size:         int64(len(STAT_CONTENT)),
+		ContentType:  STAT_CONTENT_TYPE,
+		LastModified: lastModified,
+		ETag:         STAT_ETAG,
+		AcceptRanges: true,
+	}, info)
+
+	for _, request := range s.requests {
+		s.True(strings.HasPrefix(request, http.MethodHead), "unexpected request %s", request)
+	}
+}
+
+func (s *StatTestSuite) TestStatShouldDetectCompressionFromContentType() {
+	info, err := Stat(s.server.URL + STAT_ARCHIVE_PATH)
+	s.Require().NoError(err)
+	s.Equal(COMPRESSION_GZIP, info.Compression)
+	s.False(info.AcceptRanges)
+}
+
+func (s *StatTestSuite) TestStatShouldDescribeLocalFile() {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(STAT_CONTENT))
+	zw.Close()
+
+	path := filepath.Join(s.T().TempDir(), "data.bin")
+	s.Require().NoError(os.WriteFile(path, buf.Bytes(), 0o644))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.Require().NoError(os.Chtimes(path, modTime, modTime))
+
+	info, err := Stat(SCHEME_FILE_PREFIX + path)
+	s.Require().NoError(err)
+	s.Equal(SCHEME_FILE_PREFIX+path, info.URL)
+	s.Equal("data.bin", info.Filename)
+	s.Equal(int64(buf.Len()), info.Size)
+	s.True(info.LastModified.Equal(modTime))
+	s.True(info.AcceptRanges)
+	s.Equal(COMPRESSION_GZIP, info.Compression)
+}
+
+func (s *StatTestSuite) TestStatShouldFallBackForCustomSchemes() {
+	RegisterScheme("stattest", func(u *url.URL, opts Options) (SourceReader, error) {
+		return &memSource{Reader: strings.NewReader(STAT_CONTENT), name: "notes.txt.zst"}, nil
+	})
+
+	info, err := Stat("stattest://notes")
+	s.Require().NoError(err)
+	s.Equal("stattest://notes", info.URL)
+	s.Equal("notes.txt.zst", info.Filename)
+	s.Equal(int64(len(STAT_CONTENT)), info.Size)
+	s.Equal(COMPRESSION_ZSTD, info.Compression)
+}
+
+func (s *StatTestSuite) TestStatShouldReturnSourceErrors() {
+	_, err := Stat(s.server.URL + "/missing")
+	s.ErrorIs(err, apperrors.ErrURLNotExists)
+
+	_, err = Stat("ftp://example.com/file")
+	s.ErrorIs(err, apperrors.ErrUnsupportedScheme)
+}
@@ -0,0 +1,89 @@
+package reader
+
+import (
+	"bufio"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	SCHEME_STDIN = "stdin"
+
+	SCHEME_STDIN_PREFIX = SCHEME_STDIN + SCHEME_SUFFIX
+
+	// STDIN_SOURCE is the conventional shell name for standard input.
+	STDIN_SOURCE = "-"
+
+	STDIN_FILENAME     = "stdin"
+	STDIN_SIZE_UNKNOWN = -1
+
+	// SNIFF_SIZE is what http.DetectContentType looks at.
+	SNIFF_SIZE = 512
+)
+
+// StdinReader reads a source piped into the process. Its size is unknown
+// up front. Without a name from stdin://name, the filename is derived from
+// the first bytes of the content once it is asked for.
+type StdinReader struct {
+	reader   *bufio.Reader
+	filename string
+}
+
+func NewStdinReader(filename string, opts ...Option) *StdinReader {
+	return newStdinReader(filename, newOptions(opts))
+}
+
+func newStdinReader(filename string, options Options) *StdinReader {
+	in := options.Stdin
+	if in == nil {
+		in = os.Stdin
+	}
+	return &StdinReader{reader: bufio.NewReaderSize(in, SNIFF_SIZE), filename: filename}
+}
+
+func newStdinSource(u *url.URL, opts Options) (SourceReader, error) {
+	name := strings.Trim(u.Host+u.Path, "/")
+	if u.Opaque != "" {
+		name = strings.Trim(u.Opaque, "/")
+	}
+	return newStdinReader(name, opts), nil
+}
+
+func (r *StdinReader) Filename() string {
+	if r.filename == "" {
+		r.filename = r.sniffFilename()
+	}
+	return r.filename
+}
+
+func (r *StdinReader) TotalSize() int64 {
+	return STDIN_SIZE_UNKNOWN
+}
+
+func (r *StdinReader) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+// sniffFilename peeks at the content without consuming it. Compression is
+// checked first since http.DetectContentType knows only a few formats.
+func (r *StdinReader) sniffFilename() string {
+	header, _ := r.reader.Peek(SNIFF_SIZE)
+	if len(header) == 0 {
+		return STDIN_FILENAME
+	}
+	if format := detectCompressionByMagic(header); format != COMPRESSION_NONE {
+		return STDIN_FILENAME + compressionFileExtension(format)
+	}
+	return STDIN_FILENAME + extensionForContentType(http.DetectContentType(header))
+}
+
+func compressionFileExtension(format CompressionFormat) string {
+	for _, e := range compressionExtensions {
+		if e.format == format && e.replacement == "" {
+			return e.ext
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	STDIN_TEXT_CONTENT = "piped from a shell\n"
+	STDIN_NAMED_SOURCE = SCHEME_STDIN_PREFIX + "report.csv"
+)
+
+type StdinReaderTestSuite struct {
+	suite.Suite
+}
+
+func TestStdinReaderTestSuite(t *testing.T) {
+	suite.Run(t, new(StdinReaderTestSuite))
+}
+
+func (s *StdinReaderTestSuite) gzipped(content string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := io.WriteString(w, content)
+	s.Require().NoError(err)
+	s.Require().NoError(w.Close())
+	return buf.Bytes()
+}
+
+func (s *StdinReaderTestSuite) TestNewReaderShouldReadStdinForDash() {
+	r, err := NewReader(STDIN_SOURCE, WithStdin(strings.NewReader(STDIN_TEXT_CONTENT)))
+	s.Require().NoError(err)
+
+	s.Equal(STDIN_SOURCE, r.Source())
+	s.Equal(int64(STDIN_SIZE_UNKNOWN), r.TotalSize())
+	s.Equal(STDIN_FILENAME+".txt", r.Filename())
+
+	data, err := io.ReadAll(r)
+	s.NoError(err)
+	s.Equal(STDIN_TEXT_CONTENT, string(data))
+}
+
+func (s *StdinReaderTestSuite) TestNewReaderShouldUseNameFromStdinURL() {
+	r, err := NewReader(STDIN_NAMED_SOURCE, WithStdin(strings.NewReader(STDIN_TEXT_CONTENT)))
+	s.Require().NoError(err)
+	s.Equal("report.csv", r.Filename())
+}
+
+func (s *StdinReaderTestSuite) TestFilenameShouldBeSniffedFromContent() {
+	s.Equal(STDIN_FILENAME, NewStdinReader("", WithStdin(strings.NewReader(""))).Filename())
+	s.Equal(STDIN_FILENAME+".gz", NewStdinReader("", WithStdin(bytes.NewReader(s.gzipped(STDIN_TEXT_CONTENT)))).Filename())
+	s.Equal(STDIN_FILENAME+".html", NewStdinReader("", WithStdin(strings.NewReader("<!DOCTYPE html><p>hi"))).Filename())
+}
+
+func (s *StdinReaderTestSuite) TestStreamToFileShouldSaveStdin() {
+	r, err := NewReader(STDIN_SOURCE, WithStdin(strings.NewReader(STDIN_TEXT_CONTENT)))
+	s.Require().NoError(err)
+
+	path, n, err := r.StreamToFile(s.T().TempDir())
+	s.NoError(err)
+	s.Equal(STDIN_FILENAME+".txt", filepath.Base(path))
+	s.Equal(int64(len(STDIN_TEXT_CONTENT)), n)
+
+	data, err := os.ReadFile(path)
+	s.NoError(err)
+	s.Equal(STDIN_TEXT_CONTENT, string(data))
+}
+
+func (s *StdinReaderTestSuite) TestStreamToFileShouldDecompressStdin() {
+	stdin := bytes.NewReader(s.gzipped(STDIN_TEXT_CONTENT))
+	r, err := NewReader(STDIN_SOURCE, WithStdin(stdin), WithDecompression(DECOMPRESS_AUTO))
+	s.Require().NoError(err)
+
+	path, _, err := r.StreamToFile(s.T().TempDir())
+	s.NoError(err)
+	s.Equal(STDIN_FILENAME, filepath.Base(path))
+
+	data, err := os.ReadFile(path)
+	s.NoError(err)
+	s.Equal(STDIN_TEXT_CONTENT, string(data))
+}
+
+func (s *StdinReaderTestSuite) TestNotifyProgressShouldReportUnknownSize() {
+	r, err := NewReader(STDIN_SOURCE, WithStdin(strings.NewReader(STDIN_TEXT_CONTENT)))
+	s.Require().NoError(err)
+
+	out := captureStderr(func() {
+		pr := &ProgressReader{Reader: r, TotalSize: r.TotalSize(), Notify: NotifyProgress}
+		_, err = io.Copy(io.Discard, pr)
+	})
+	s.NoError(err)
+	s.Contains(out, "Downloaded 19 bytes...")
+	s.NotContains(out, "%")
+}
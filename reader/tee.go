@@ -0,0 +1,405 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apperrors "abc/errors"
+
+	"github.com/google/uuid"
+)
+
+const TEE_DEFAULT_BUFFER = 16
+
+// Sink receives one copy of a teed stream. Commit is called once the whole
+// content was written and verified. Abort is called instead when the
+// transfer fails or the sink is dropped; after a write timeout it may run
+// while a Write is still blocked.
+type Sink interface {
+	io.Writer
+	Commit() error
+	Abort(err error)
+}
+
+type SinkErrorPolicy int
+
+const (
+	// SINK_ABORT_ON_ERROR fails the whole transfer when the sink fails.
+	SINK_ABORT_ON_ERROR SinkErrorPolicy = iota
+	// SINK_DROP_ON_ERROR aborts only the failing sink and keeps going.
+	SINK_DROP_ON_ERROR
+)
+
+// TeeSink configures one destination of Tee. Every sink is written from its
+// own goroutine through a queue of Buffer chunks, so a slow sink holds the
+// others back only once its queue is full. A WriteTimeout turns a sink that
+// stays full for that long into a failure.
+type TeeSink struct {
+	Name         string
+	Sink         Sink
+	OnError      SinkErrorPolicy
+	Buffer       int
+	WriteTimeout time.Duration
+}
+
+type SinkError struct {
+	Name string
+	Err  error
+}
+
+func (e *SinkError) Error() string {
+	return fmt.Sprintf("sink %s: %s", e.Name, e.Err)
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+// SinkResult tells how far a sink got. Err is set for dropped sinks and for
+// sinks whose Commit failed.
+type SinkResult struct {
+	Name    string
+	Written int64
+	Dropped bool
+	Err     error
+}
+
+type TeeResult struct {
+	Size     int64
+	Verified []ChecksumAlgorithm
+	Sinks    []SinkResult
+}
+
+// Tee streams the source to every sink in a single pass, with the same
+// progress reporting, rate limiting and checksum verification as StreamTo.
+// Sinks are committed only after verification succeeds. Options about the
+// destination file are ignored.
+func (r *Reader) Tee(sinks []TeeSink, opts ...StreamOption) (TeeResult, error) {
+	return r.TeeContext(context.Background(), sinks, opts...)
+}
+
+func (r *Reader) TeeContext(ctx context.Context, sinks []TeeSink, opts ...StreamOption) (TeeResult, error) {
+	if r.src == nil {
+		return TeeResult{}, apperrors.ErrReaderSourceNil
+	}
+
+	options := newStreamOptions(opts)
+	checksums := r.checksums(options)
+	verifier, err := newChecksumVerifier(checksums)
+	if err != nil {
+		return TeeResult{}, err
+	}
+
+	ctx, release := r.bindContext(ctx)
+	defer release()
+
+	tee := newTeeWriter(sinks)
+	pr := r.progressReader(ctx, options, 0)
+	n, err := r.copyDecompressed(tee, pr, verifier)
+	if err == nil {
+		err = tee.drain()
+	}
+	if err == nil {
+		if len(checksums) > 0 {
+			pr.SetPhase(PHASE_VERIFYING)
+		}
+		err = verifier.verify("")
+	}
+	if err != nil {
+		tee.abort(err)
+		return tee.result(n, nil), err
+	}
+
+	result := tee.result(n, checksums)
+	err = tee.commit(result.Sinks)
+	if err == nil {
+		pr.SetPhase(PHASE_DONE)
+	}
+	return result, err
+}
+
+type teeBranch struct {
+	TeeSink
+	chunks  chan []byte
+	done    chan struct{}
+	written atomic.Int64
+	stopped atomic.Bool
+	err     error
+
+	closeOnce sync.Once
+	failure   error
+	finished  bool
+}
+
+func newTeeBranch(sink TeeSink) *teeBranch {
+	if sink.Buffer <= 0 {
+		sink.Buffer = TEE_DEFAULT_BUFFER
+	}
+	b := &teeBranch{
+		TeeSink: sink,
+		chunks:  make(chan []byte, sink.Buffer),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *teeBranch) run() {
+	defer close(b.done)
+	for chunk := range b.chunks {
+		if b.stopped.Load() {
+			continue
+		}
+		n, err := b.Sink.Write(chunk)
+		b.written.Add(int64(n))
+		if err == nil && n < len(chunk) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			b.err = err
+			return
+		}
+	}
+}
+
+func (b *teeBranch) send(chunk []byte) error {
+	select {
+	case <-b.done:
+		return b.err
+	default:
+	}
+
+	timeout, stop := b.timer()
+	defer stop()
+	select {
+	case b.chunks <- chunk:
+		return nil
+	case <-b.done:
+		return b.err
+	case <-timeout:
+		return apperrors.ErrSinkTimeout
+	}
+}
+
+// finish waits for the queued chunks to be written.
+func (b *teeBranch) finish() error {
+	b.closeOnce.Do(func() { close(b.chunks) })
+	timeout, stop := b.timer()
+	defer stop()
+	select {
+	case <-b.done:
+		return b.err
+	case <-timeout:
+		return apperrors.ErrSinkTimeout
+	}
+}
+
+// stop discards whatever is still queued and aborts the sink.
+func (b *teeBranch) stop(err error) {
+	b.stopped.Store(true)
+	b.closeOnce.Do(func() { close(b.chunks) })
+	if !errors.Is(err, apperrors.ErrSinkTimeout) {
+		<-b.done
+	}
+	b.Sink.Abort(err)
+}
+
+func (b *teeBranch) timer() (<-chan time.Time, func()) {
+	if b.WriteTimeout <= 0 {
+		return nil, func() {}
+	}
+	t := time.NewTimer(b.WriteTimeout)
+	return t.C, func() { t.Stop() }
+}
+
+type teeWriter struct {
+	branches []*teeBranch
+}
+
+func newTeeWriter(sinks []TeeSink) *teeWriter {
+	t := &teeWriter{}
+	for _, sink := range sinks {
+		t.branches = append(t.branches, newTeeBranch(sink))
+	}
+	return t
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	// sinks keep chunks queued past this call, so p cannot be shared
+	chunk := append([]byte(nil), p...)
+	for _, b := range t.branches {
+		if b.failure != nil {
+			continue
+		}
+		if err := b.send(chunk); err != nil {
+			if err := t.fail(b, err); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if !t.active() {
+		return 0, apperrors.ErrNoSinks
+	}
+	return len(p), nil
+}
+
+// fail records the failure of b and returns an error when it should end the
+// transfer. A dropped sink is aborted right away.
+func (t *teeWriter) fail(b *teeBranch, err error) error {
+	b.failure = &SinkError{Name: b.Name, Err: err}
+	if b.OnError == SINK_ABORT_ON_ERROR {
+		return b.failure
+	}
+	b.stop(b.failure)
+	b.finished = true
+	return nil
+}
+
+func (t *teeWriter) active() bool {
+	for _, b := range t.branches {
+		if b.failure == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *teeWriter) drain() error {
+	for _, b := range t.branches {
+		if b.failure != nil {
+			continue
+		}
+		if err := b.finish(); err != nil {
+			if err := t.fail(b, err); err != nil {
+				return err
+			}
+		}
+	}
+	if !t.active() {
+		return apperrors.ErrNoSinks
+	}
+	return nil
+}
+
+func (t *teeWriter) abort(err error) {
+	for _, b := range t.branches {
+		if b.finished {
+			continue
+		}
+		cause := err
+		if b.failure != nil {
+			cause = b.failure
+		}
+		b.stop(cause)
+		b.finished = true
+	}
+}
+
+// commit commits every sink still standing. A sink that fails to commit is
+// aborted and reported in its result, and fails the transfer when it is not
+// droppable.
+func (t *teeWriter) commit(results []SinkResult) error {
+	var errs []error
+	for i, b := range t.branches {
+		if b.failure != nil {
+			continue
+		}
+		b.finished = true
+		if err := b.Sink.Commit(); err != nil {
+			b.failure = &SinkError{Name: b.Name, Err: err}
+			b.Sink.Abort(b.failure)
+			results[i].Err = b.failure
+			if b.OnError == SINK_ABORT_ON_ERROR {
+				errs = append(errs, b.failure)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *teeWriter) result(n int64, checksums []Checksum) TeeResult {
+	result := TeeResult{Size: n}
+	for _, c := range checksums {
+		result.Verified = append(result.Verified, c.Algorithm)
+	}
+	for _, b := range t.branches {
+		sr := SinkResult{Name: b.Name, Written: b.written.Load(), Err: b.failure}
+		sr.Dropped = b.failure != nil && b.OnError == SINK_DROP_ON_ERROR
+		result.Sinks = append(result.Sinks, sr)
+	}
+	return result
+}
+
+// FileSink writes to a part file next to its destination and moves it into
+// place on Commit, resolving collisions like StreamToFile does.
+type FileSink struct {
+	path     string
+	tempPath string
+	policy   CollisionPolicy
+	file     *os.File
+	action   FinalizeAction
+}
+
+func NewFileSink(path string, policy CollisionPolicy) (*FileSink, error) {
+	tempPath := filepath.Join(filepath.Dir(path), uuid.New().String()+PART_FILE_SUFFIX)
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return nil, destinationError(err)
+	}
+	return &FileSink{path: path, tempPath: tempPath, policy: policy, file: file}, nil
+}
+
+func (s *FileSink) Write(p []byte) (int, error) {
+	n, err := s.file.Write(p)
+	return n, destinationError(err)
+}
+
+func (s *FileSink) Commit() error {
+	if err := s.file.Sync(); err != nil {
+		return destinationError(err)
+	}
+	if err := s.file.Close(); err != nil {
+		return destinationError(err)
+	}
+	path, action, err := finalizeFile(s.tempPath, s.path, s.policy)
+	if path != "" {
+		s.path = path
+	}
+	s.action = action
+	return err
+}
+
+func (s *FileSink) Abort(err error) {
+	s.file.Close()
+	os.Remove(s.tempPath)
+}
+
+// Path is the destination, which may differ from the requested one once
+// Commit resolved a collision.
+func (s *FileSink) Path() string {
+	return s.path
+}
+
+func (s *FileSink) Action() FinalizeAction {
+	return s.action
+}
+
+type writerSink struct {
+	io.Writer
+}
+
+func (writerSink) Commit() error { return nil }
+
+func (writerSink) Abort(error) {}
+
+// WriterSink adapts w, such as a hash, to a Sink that needs no finalization.
+func WriterSink(w io.Writer) Sink {
+	return writerSink{Writer: w}
+}
@@ -0,0 +1,247 @@
+package reader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apperrors "abc/errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	TEE_FILE_NAME     = "artifact.bin"
+	TEE_WRITE_TIMEOUT = 50 * time.Millisecond
+)
+
+var (
+	TEE_CONTENT     = strings.Repeat("tee me to many places\n", 8192)
+	errSinkRejected = errors.New("sink rejected write")
+)
+
+type recordingSink struct {
+	mu         sync.Mutex
+	buf        bytes.Buffer
+	fail       bool
+	failCommit bool
+	block      chan struct{}
+	committed  bool
+	aborted    error
+}
+
+func (s *recordingSink) Write(p []byte) (int, error) {
+	if s.block != nil {
+		<-s.block
+	}
+	if s.fail {
+		return 0, errSinkRejected
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *recordingSink) Commit() error {
+	s.committed = true
+	if s.failCommit {
+		return errSinkRejected
+	}
+	return nil
+}
+
+func (s *recordingSink) Abort(err error) {
+	s.aborted = err
+	if s.block != nil {
+		close(s.block)
+	}
+}
+
+type TeeTestSuite struct {
+	suite.Suite
+	source string
+}
+
+func TestTeeTestSuite(t *testing.T) {
+	suite.Run(t, new(TeeTestSuite))
+}
+
+func (s *TeeTestSuite) SetupTest() {
+	s.source = filepath.Join(s.T().TempDir(), TEE_FILE_NAME)
+	s.Require().NoError(os.WriteFile(s.source, []byte(TEE_CONTENT), 0o644))
+}
+
+func (s *TeeTestSuite) reader() *Reader {
+	r, err := NewReader(SCHEME_FILE_PREFIX + s.source)
+	s.Require().NoError(err)
+	return r
+}
+
+func (s *TeeTestSuite) fileSink(dir string) *FileSink {
+	sink, err := NewFileSink(filepath.Join(dir, TEE_FILE_NAME), COLLISION_RENAME)
+	s.Require().NoError(err)
+	return sink
+}
+
+func (s *TeeTestSuite) assertEmptyDir(dir string) {
+	entries, err := os.ReadDir(dir)
+	s.Require().NoError(err)
+	s.Empty(entries)
+}
+
+func (s *TeeTestSuite) TestTeeShouldWriteEverySinkInOnePass() {
+	first, second := s.T().TempDir(), s.T().TempDir()
+	firstSink, secondSink := s.fileSink(first), s.fileSink(second)
+	hash := sha256.New()
+
+	result, err := s.reader().Tee([]TeeSink{
+		{Name: "first", Sink: firstSink},
+		{Name: "second", Sink: secondSink},
+		{Name: "hash", Sink: WriterSink(hash)},
+	})
+	s.Require().NoError(err)
+	s.Equal(int64(len(TEE_CONTENT)), result.Size)
+
+	for _, path := range []string{firstSink.Path(), secondSink.Path()} {
+		data, err := os.ReadFile(path)
+		s.Require().NoError(err)
+		s.Equal(TEE_CONTENT, string(data))
+	}
+	s.Equal(filepath.Join(first, TEE_FILE_NAME), firstSink.Path())
+	s.Equal(ACTION_CREATED, firstSink.Action())
+
+	sum := sha256.Sum256([]byte(TEE_CONTENT))
+	s.Equal(sum[:], hash.Sum(nil))
+
+	s.Len(result.Sinks, 3)
+	for _, sr := range result.Sinks {
+		s.Equal(int64(len(TEE_CONTENT)), sr.Written)
+		s.False(sr.Dropped)
+		s.NoError(sr.Err)
+	}
+}
+
+func (s *TeeTestSuite) TestTeeShouldDropFailingSink() {
+	good, bad := &recordingSink{}, &recordingSink{fail: true}
+
+	result, err := s.reader().Tee([]TeeSink{
+		{Name: "good", Sink: good},
+		{Name: "bad", Sink: bad, OnError: SINK_DROP_ON_ERROR},
+	})
+	s.Require().NoError(err)
+
+	s.True(good.committed)
+	s.Equal(TEE_CONTENT, good.buf.String())
+	s.False(bad.committed)
+	s.ErrorIs(bad.aborted, errSinkRejected)
+
+	s.True(result.Sinks[1].Dropped)
+	var sinkErr *SinkError
+	s.Require().ErrorAs(result.Sinks[1].Err, &sinkErr)
+	s.Equal("bad", sinkErr.Name)
+}
+
+func (s *TeeTestSuite) TestTeeShouldAbortEverySinkWhenSinkFails() {
+	dir := s.T().TempDir()
+	good, bad := &recordingSink{}, &recordingSink{fail: true}
+
+	result, err := s.reader().Tee([]TeeSink{
+		{Name: "file", Sink: s.fileSink(dir)},
+		{Name: "good", Sink: good},
+		{Name: "bad", Sink: bad},
+	})
+	s.ErrorIs(err, errSinkRejected)
+	s.ErrorContains(err, "sink bad")
+
+	s.assertEmptyDir(dir)
+	s.False(good.committed)
+	s.ErrorIs(good.aborted, errSinkRejected)
+	s.False(result.Sinks[2].Dropped)
+}
+
+func (s *TeeTestSuite) TestFileSinkWriteFailuresShouldBeDestinationErrors() {
+	sink := s.fileSink(s.T().TempDir())
+	defer sink.Abort(nil)
+	written := sink.file
+	sink.file = readOnlyFile(s.T())
+	defer func() { sink.file = written }()
+
+	_, err := sink.Write([]byte(FILE_LOCAL_CONTENT))
+	var destErr *apperrors.DestinationError
+	s.True(errors.As(err, &destErr))
+}
+
+func (s *TeeTestSuite) TestTeeShouldDropSlowSink() {
+	good, slow := &recordingSink{}, &recordingSink{block: make(chan struct{})}
+
+	result, err := s.reader().Tee([]TeeSink{
+		{Name: "good", Sink: good},
+		{Name: "slow", Sink: slow, OnError: SINK_DROP_ON_ERROR, Buffer: 1, WriteTimeout: TEE_WRITE_TIMEOUT},
+	})
+	s.Require().NoError(err)
+
+	s.Equal(TEE_CONTENT, good.buf.String())
+	s.True(result.Sinks[1].Dropped)
+	s.ErrorIs(result.Sinks[1].Err, apperrors.ErrSinkTimeout)
+	s.ErrorIs(slow.aborted, apperrors.ErrSinkTimeout)
+}
+
+func (s *TeeTestSuite) TestTeeShouldFailWhenEverySinkIsDropped() {
+	_, err := s.reader().Tee([]TeeSink{
+		{Name: "bad", Sink: &recordingSink{fail: true}, OnError: SINK_DROP_ON_ERROR},
+	})
+	s.ErrorIs(err, apperrors.ErrNoSinks)
+}
+
+func (s *TeeTestSuite) TestTeeShouldNotCommitOnChecksumMismatch() {
+	dir := s.T().TempDir()
+	good := &recordingSink{}
+
+	_, err := s.reader().Tee([]TeeSink{
+		{Name: "file", Sink: s.fileSink(dir)},
+		{Name: "good", Sink: good},
+	}, WithChecksum(CHECKSUM_SHA256, strings.Repeat("0", 64)))
+	s.ErrorIs(err, apperrors.ErrChecksumMismatch)
+
+	s.assertEmptyDir(dir)
+	s.False(good.committed)
+	s.ErrorIs(good.aborted, apperrors.ErrChecksumMismatch)
+}
+
+func (s *TeeTestSuite) TestTeeShouldVerifyChecksums() {
+	sum := sha256.Sum256([]byte(TEE_CONTENT))
+
+	result, err := s.reader().Tee([]TeeSink{{Name: "good", Sink: &recordingSink{}}},
+		WithChecksum(CHECKSUM_SHA256, hex.EncodeToString(sum[:])))
+	s.Require().NoError(err)
+	s.Equal([]ChecksumAlgorithm{CHECKSUM_SHA256}, result.Verified)
+}
+
+func (s *TeeTestSuite) TestTeeShouldReportCommitFailures() {
+	good, bad := &recordingSink{}, &recordingSink{failCommit: true}
+
+	result, err := s.reader().Tee([]TeeSink{
+		{Name: "good", Sink: good},
+		{Name: "bad", Sink: bad, OnError: SINK_DROP_ON_ERROR},
+	})
+	s.NoError(err)
+	s.True(good.committed)
+	s.ErrorIs(result.Sinks[1].Err, errSinkRejected)
+	s.ErrorIs(bad.aborted, errSinkRejected)
+
+	_, err = s.reader().Tee([]TeeSink{{Name: "bad", Sink: &recordingSink{failCommit: true}}})
+	s.ErrorIs(err, errSinkRejected)
+}
+
+func (s *TeeTestSuite) TestTeeShouldReturnErrorIfReaderIsNil() {
+	r := &Reader{}
+	_, err := r.Tee(nil)
+	s.ErrorIs(err, apperrors.ErrReaderSourceNil)
+}